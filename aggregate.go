@@ -0,0 +1,259 @@
+package jc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Aggregate builds aggregation pipeline over collection of the model
+type Aggregate struct {
+	Database    string
+	collection  string
+	model       *Collection
+	pipeline    []interface{}
+	result      interface{}
+	resultType  reflect.Type
+	singleValue bool
+	loadModels  bool
+	withDeleted bool
+	err         error
+}
+
+// NewAggregate creates aggregation over collection of supplied model. Result
+// is pointer to a struct, map or slice the output is decoded into. If result
+// holds documents, they are initialized the same way as query results.
+// Result can be nil for pipelines that are used only as facets.
+func NewAggregate(model document, result interface{}) (newAggregate Aggregate, err error) {
+	if !model.IsInitialized() {
+		err = NewDocument(model)
+		if err != nil {
+			return
+		}
+	}
+	newAggregate.model = model.base()
+	newAggregate.err = newAggregate.model._tagError
+	newAggregate.collection = model.CollectionName()
+	newAggregate.Database = model.Database()
+	newAggregate.result = result
+
+	if result == nil {
+		return
+	}
+	resultType := reflect.TypeOf(result)
+	if resultType.Kind() != reflect.Ptr {
+		err = errors.New("Supplied 'result' must be a pointer.")
+		return
+	}
+
+	resultType = resultType.Elem()
+	newAggregate.singleValue = resultType.Kind() != reflect.Slice
+	if !newAggregate.singleValue {
+		resultType = resultType.Elem()
+	}
+	newAggregate.resultType = resultType
+	newAggregate.loadModels = reflect.PtrTo(resultType).Implements(reflect.TypeOf((*document)(nil)).Elem())
+	return
+}
+
+// Match filters documents entering next stage. Filter can be a map, a struct
+// or a Condition.
+func (a *Aggregate) Match(filter interface{}) *Aggregate {
+	if condition, ok := filter.(Condition); ok {
+		built, err := condition.build(a.key)
+		if err != nil {
+			a.setErr(err)
+			return a
+		}
+		filter = built
+	}
+	return a.Stage(bson.M{"$match": filter})
+}
+
+func (a *Aggregate) Group(group interface{}) *Aggregate {
+	return a.Stage(bson.M{"$group": group})
+}
+
+func (a *Aggregate) Project(projection interface{}) *Aggregate {
+	return a.Stage(bson.M{"$project": projection})
+}
+
+// Unwind deconstructs array field, outputting one document per element
+func (a *Aggregate) Unwind(field string) *Aggregate {
+	key, err := a.key(field)
+	if err != nil {
+		a.setErr(err)
+		return a
+	}
+	return a.Stage(bson.M{"$unwind": "$" + key})
+}
+
+// Lookup joins documents from another collection whose foreignField equals
+// localField of the current document and stores them in field 'as'.
+func (a *Aggregate) Lookup(from string, localField string, foreignField string, as string) *Aggregate {
+	key, err := a.key(localField)
+	if err != nil {
+		a.setErr(err)
+		return a
+	}
+	return a.Stage(bson.M{"$lookup": bson.M{
+		"from":         from,
+		"localField":   key,
+		"foreignField": foreignField,
+		"as":           as,
+	}})
+}
+
+// Sort orders documents by given fields, prefix '-' denotes descending order
+func (a *Aggregate) Sort(fields ...string) *Aggregate {
+	order := bson.D{}
+	for _, field := range fields {
+		direction := 1
+		if strings.HasPrefix(field, "-") {
+			direction, field = -1, field[1:]
+		}
+		key, err := a.key(field)
+		if err != nil {
+			a.setErr(err)
+			return a
+		}
+		order = append(order, bson.DocElem{Name: key, Value: direction})
+	}
+	return a.Stage(bson.M{"$sort": order})
+}
+
+func (a *Aggregate) Skip(skip int) *Aggregate {
+	return a.Stage(bson.M{"$skip": skip})
+}
+
+func (a *Aggregate) Limit(limit int) *Aggregate {
+	return a.Stage(bson.M{"$limit": limit})
+}
+
+// Facet processes documents by multiple pipelines at once. Output document
+// holds result of each pipeline under its name.
+func (a *Aggregate) Facet(facets map[string]*Aggregate) *Aggregate {
+	stage := bson.M{}
+	for name, facet := range facets {
+		if facet.err != nil {
+			a.setErr(facet.err)
+			return a
+		}
+		stage[name] = facet.pipeline
+	}
+	return a.Stage(bson.M{"$facet": stage})
+}
+
+// Stage appends arbitrary stage to the pipeline
+func (a *Aggregate) Stage(stage interface{}) *Aggregate {
+	a.pipeline = append(a.pipeline, stage)
+	return a
+}
+
+// WithDeleted makes aggregation include soft deleted documents
+func (a *Aggregate) WithDeleted() *Aggregate {
+	a.withDeleted = true
+	return a
+}
+
+func (a *Aggregate) GetPipeline() []interface{} {
+	if !a.model._softDelete || a.withDeleted {
+		return a.pipeline
+	}
+	return append([]interface{}{bson.M{"$match": bson.M{deletedAtKey: nil}}}, a.pipeline...)
+}
+
+func (a *Aggregate) Collection() string {
+	return a.collection
+}
+
+// Err returns first error encountered while building the pipeline
+func (a *Aggregate) Err() error {
+	return a.err
+}
+
+// Execute runs the pipeline and decodes its output into result
+func (a *Aggregate) Execute(reuseSocket bool) error {
+	return a.ExecuteCtx(context.Background(), reuseSocket)
+}
+
+// ExecuteCtx is Execute bound by the context
+func (a *Aggregate) ExecuteCtx(ctx context.Context, reuseSocket bool) (err error) {
+	if a.err != nil {
+		return a.err
+	}
+	if a.result == nil {
+		return errors.New("aggregation has no result to decode output into")
+	}
+
+	session, err := getSession(a.model._connection, reuseSocket)
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	if a.model._readMode != nil {
+		session.SetMode(*a.model._readMode, true)
+	}
+
+	// Output is fetched raw and decoded only once the pipeline finishes, so
+	// that result is not filled after cancellation
+	var raw bson.Raw
+	var raws []bson.Raw
+	database, collection, pipeline, single := a.Database, a.collection, a.GetPipeline(), a.singleValue
+	err = run(ctx, session, func(s *mgo.Session) error {
+		pipe := s.DB(database).C(collection).Pipe(pipeline)
+		if single {
+			return pipe.One(&raw)
+		}
+		return pipe.All(&raws)
+	})
+	if err != nil {
+		return
+	}
+
+	if a.loadModels {
+		if a.singleValue {
+			return loadDocument(raw, reflect.ValueOf(a.result), a.resultType, nil)
+		}
+		return loadSlice(raws, a.result, a.resultType, nil)
+	}
+
+	if a.singleValue {
+		return raw.Unmarshal(a.result)
+	}
+	return unmarshalSlice(raws, a.result)
+}
+
+// unmarshalSlice decodes raw documents into slice pointed to by result
+func unmarshalSlice(raws []bson.Raw, result interface{}) error {
+	slice := reflect.ValueOf(result).Elem()
+	slice.Set(reflect.MakeSlice(slice.Type(), len(raws), len(raws)))
+	for i, raw := range raws {
+		err := raw.Unmarshal(slice.Index(i).Addr().Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key translates field name through model. Documents change their shape as
+// they pass through the pipeline, so unknown names are used as they are.
+func (a *Aggregate) key(field string) (string, error) {
+	if key, err := a.model.bsonKey(field); err == nil {
+		return key, nil
+	}
+	if field == "" {
+		return "", errors.New("empty field name in aggregation")
+	}
+	return field, nil
+}
+
+func (a *Aggregate) setErr(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
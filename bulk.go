@@ -0,0 +1,160 @@
+package jc
+
+import (
+	"context"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Bulk operations act on documents matching query filter directly in DB.
+// Documents are not loaded, so hooks, validation, timestamps and versions
+// don't apply. Sort, skip, limit and projection of the query are ignored.
+
+// UpdateAll applies update to all documents matching the query
+func (q *Query) UpdateAll(reuseSocket bool, update interface{}) (*mgo.ChangeInfo, error) {
+	return q.UpdateAllCtx(context.Background(), reuseSocket, update)
+}
+
+// UpdateAllCtx is UpdateAll bound by the context
+func (q *Query) UpdateAllCtx(ctx context.Context, reuseSocket bool, update interface{}) (*mgo.ChangeInfo, error) {
+	update, err := detached(ctx, update)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := q.selector()
+	return q.write(ctx, reuseSocket, func(collection *mgo.Collection) (*mgo.ChangeInfo, error) {
+		return collection.UpdateAll(selector, update)
+	})
+}
+
+// UpdateOne applies update to single document matching the query. It returns
+// ErrNotFound if there is no such document.
+func (q *Query) UpdateOne(reuseSocket bool, update interface{}) (*mgo.ChangeInfo, error) {
+	return q.UpdateOneCtx(context.Background(), reuseSocket, update)
+}
+
+// UpdateOneCtx is UpdateOne bound by the context
+func (q *Query) UpdateOneCtx(ctx context.Context, reuseSocket bool, update interface{}) (*mgo.ChangeInfo, error) {
+	update, err := detached(ctx, update)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := q.selector()
+	return q.write(ctx, reuseSocket, func(collection *mgo.Collection) (*mgo.ChangeInfo, error) {
+		return updateOne(collection, selector, update)
+	})
+}
+
+// DeleteAll removes all documents matching the query. Documents of models with
+// soft delete are only marked as deleted.
+func (q *Query) DeleteAll(reuseSocket bool) (*mgo.ChangeInfo, error) {
+	return q.DeleteAllCtx(context.Background(), reuseSocket)
+}
+
+// DeleteAllCtx is DeleteAll bound by the context
+func (q *Query) DeleteAllCtx(ctx context.Context, reuseSocket bool) (*mgo.ChangeInfo, error) {
+	selector, soft, now := q.selector(), q.model._softDelete, timestamp()
+	return q.write(ctx, reuseSocket, func(collection *mgo.Collection) (*mgo.ChangeInfo, error) {
+		if soft {
+			return collection.UpdateAll(selector, bson.M{"$set": bson.M{deletedAtKey: now}})
+		}
+		return collection.RemoveAll(selector)
+	})
+}
+
+// DeleteOne removes single document matching the query. Document of model with
+// soft delete is only marked as deleted. It returns ErrNotFound if there is no
+// such document.
+func (q *Query) DeleteOne(reuseSocket bool) (*mgo.ChangeInfo, error) {
+	return q.DeleteOneCtx(context.Background(), reuseSocket)
+}
+
+// DeleteOneCtx is DeleteOne bound by the context
+func (q *Query) DeleteOneCtx(ctx context.Context, reuseSocket bool) (*mgo.ChangeInfo, error) {
+	selector, soft, now := q.selector(), q.model._softDelete, timestamp()
+	return q.write(ctx, reuseSocket, func(collection *mgo.Collection) (*mgo.ChangeInfo, error) {
+		if soft {
+			return updateOne(collection, selector, bson.M{"$set": bson.M{deletedAtKey: now}})
+		}
+		return deleteOne(collection, selector)
+	})
+}
+
+// write runs bulk operation on collection of the query
+func (q *Query) write(ctx context.Context, reuseSocket bool, operation func(*mgo.Collection) (*mgo.ChangeInfo, error)) (*mgo.ChangeInfo, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	session, err := getSession(q.model._connection, reuseSocket)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var info *mgo.ChangeInfo
+	database, collection := q.Database, q.collection
+	err = run(ctx, session, func(s *mgo.Session) (err error) {
+		info, err = operation(s.DB(database).C(collection))
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// writeResult is reply to 'update' and 'delete' commands
+type writeResult struct {
+	N           int `bson:"n"`
+	NModified   int `bson:"nModified"`
+	WriteErrors []struct {
+		Code    int    `bson:"code"`
+		Message string `bson:"errmsg"`
+	} `bson:"writeErrors"`
+}
+
+// err returns first write error, or ErrNotFound if no document matched
+func (r *writeResult) err() error {
+	if len(r.WriteErrors) > 0 {
+		return &mgo.QueryError{Code: r.WriteErrors[0].Code, Message: r.WriteErrors[0].Message}
+	} else if r.N == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// updateOne updates single document using 'update' command, which, unlike
+// mgo.Collection.Update, reports how many documents were actually modified.
+func updateOne(collection *mgo.Collection, selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	var result writeResult
+	err := collection.Database.Run(bson.D{
+		{Name: "update", Value: collection.Name},
+		{Name: "updates", Value: []bson.M{{"q": selector, "u": update}}},
+	}, &result)
+	if err == nil {
+		err = result.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mgo.ChangeInfo{Matched: result.N, Updated: result.NModified}, nil
+}
+
+// deleteOne removes single document using 'delete' command
+func deleteOne(collection *mgo.Collection, selector interface{}) (*mgo.ChangeInfo, error) {
+	var result writeResult
+	err := collection.Database.Run(bson.D{
+		{Name: "delete", Value: collection.Name},
+		{Name: "deletes", Value: []bson.M{{"q": selector, "limit": 1}}},
+	}, &result)
+	if err == nil {
+		err = result.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mgo.ChangeInfo{Matched: result.N, Removed: result.N}, nil
+}
@@ -9,6 +9,9 @@ import (
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2"
 	"github.com/kalcok/jc/tools"
+	"time"
+	"context"
+	"sync"
 )
 
 type document interface {
@@ -23,18 +26,50 @@ type document interface {
 	Info()
 	NewImplicitID() error
 	Save(bool) (*mgo.ChangeInfo, error)
+	Insert(bool) error
+	Delete(bool) error
+	Reload(bool) error
+	SaveCtx(context.Context, bool) (*mgo.ChangeInfo, error)
+	InsertCtx(context.Context, bool) error
+	DeleteCtx(context.Context, bool) error
+	ReloadCtx(context.Context, bool) error
 	IsInitialized() bool
+	IsDirty() bool
+	ChangedFields() []string
+	IsPartial() bool
+	Validate() error
+	loaded(interface{}) error
+	base() *Collection
 }
 
+var (
+	metadataCache = make(map[reflect.Type]*Collection)
+	metadataLock  sync.RWMutex
+)
+
 type Collection struct {
 	_collectionName  string                `bson:"-"json:"-"`
 	_collectionDB    string                `bson:"-"json:"-"`
+	_connection      string                `bson:"-"json:"-"`
+	_explicitDB      string                `bson:"-"json:"-"`
+	_readMode        *mgo.Mode             `bson:"-"json:"-"`
 	_parent          reflect.Value         `bson:"-"json:"-"`
 	_parentType      reflect.Type          `bson:"-"json:"-"`
 	_hasExplicitID   bool                  `bson:"-"json:"-"`
 	_explicitIDField string                `bson:"-"json:"-"`
 	_implicitIDValue bson.ObjectId         `bson:"-"json:"-"`
 	_skeleton        []reflect.StructField `bson:"-"json:"-"`
+	_keys            map[string]string     `bson:"-"json:"-"`
+	_snapshot        bson.M                `bson:"-"json:"-"`
+	_snapshotID      interface{}           `bson:"-"json:"-"`
+	_omitted         map[string]bool       `bson:"-"json:"-"`
+	_rules           []fieldRule           `bson:"-"json:"-"`
+	_tagError        error                 `bson:"-"json:"-"`
+	_createdAt       fieldRef              `bson:"-"json:"-"`
+	_updatedAt       fieldRef              `bson:"-"json:"-"`
+	_version         fieldRef              `bson:"-"json:"-"`
+	_softDelete      bool                  `bson:"-"json:"-"`
+	_indexes         []mgo.Index           `bson:"-"json:"-"`
 	_initialized     bool                  `bson:"-",json:"-"`
 }
 
@@ -55,6 +90,12 @@ func (c *Collection) Database() string {
 	return c._collectionDB
 }
 
+// Connection returns name of the session used by the document, empty name
+// refers to master session.
+func (c *Collection) Connection() string {
+	return c._connection
+}
+
 func (c *Collection) IsInitialized() bool {
 	return c._initialized
 }
@@ -65,34 +106,295 @@ func (c *Collection) Info() {
 	fmt.Printf("Parent__ %s\n", c._parent)
 }
 
-func (c *Collection) Save(reuseSocket bool) (info *mgo.ChangeInfo, err error) {
-	var session *mgo.Session
-	var documentID interface{}
+// Save upserts document into DB. Documents that were previously loaded
+// from or saved into DB update only fields that changed since then.
+// Versioned documents are saved only if their version in DB did not change,
+// otherwise ErrStaleDocument is returned.
+func (c *Collection) Save(reuseSocket bool) (*mgo.ChangeInfo, error) {
+	return c.SaveCtx(context.Background(), reuseSocket)
+}
+
+// SaveCtx is Save bound by the context
+func (c *Collection) SaveCtx(ctx context.Context, reuseSocket bool) (info *mgo.ChangeInfo, err error) {
+	var update interface{}
+	var version int64
 	idField := "_id"
 
-	if reuseSocket {
-		session, err = tools.GetSessionClone()
+	err = c.beforeSave()
+	if err != nil {
+		return info, err
+	}
+
+	err = c.Validate()
+	if err != nil {
+		return info, err
+	}
+
+	session, err := getSession(c._connection, reuseSocket)
+	if err != nil {
+		return info, err
+	}
+	defer session.Close()
+
+	documentID := c.documentID()
+	selector := bson.M{idField: documentID}
+	now := timestamp()
+
+	if c._version.defined() {
+		version = c.version()
+		selector[c._version.key] = version
+	}
+
+	partial := c.hasSnapshot()
+	if partial {
+		update, err = c.partialUpdate(now)
 	} else {
-		session, err = tools.GetSessionCopy()
+		update, err = c.fullUpdate(ctx, session, now)
 	}
+	if err == nil {
+		update, err = detached(ctx, update)
+	}
+	if err != nil {
+		return info, err
+	} else if update == nil {
+		return &mgo.ChangeInfo{}, nil
+	}
+
+	var upserted *mgo.ChangeInfo
+	database, collection := c._collectionDB, c._collectionName
+	err = run(ctx, session, func(s *mgo.Session) (err error) {
+		if partial {
+			// Partial update must not recreate document deleted since it was loaded
+			upserted, err = updateOne(s.DB(database).C(collection), selector, update)
+		} else {
+			upserted, err = s.DB(database).C(collection).Upsert(selector, update)
+		}
+		return
+	})
 	if err != nil {
+		if c._version.defined() {
+			c.setVersion(version)
+			if mgo.IsDup(err) || err == ErrNotFound {
+				// There was no match for current version, upsert tried to insert new document
+				err = ErrStaleDocument
+			}
+		}
 		return info, err
 	}
+	info = upserted
+
+	err = c.takeSnapshot()
+	if err != nil {
+		return info, err
+	}
+	return info, c.afterSave()
+}
+
+// partialUpdate returns update of fields that changed since the last snapshot,
+// or nil if there are no changes.
+func (c *Collection) partialUpdate(now time.Time) (interface{}, error) {
+	update, err := c.changes()
+	if err != nil || len(update) == 0 {
+		return nil, err
+	}
+
+	if c._updatedAt.defined() || c._version.defined() {
+		c.touch(now)
+		update, err = c.changes()
+	}
+	return update, err
+}
+
+// fullUpdate returns document that replaces the stored one. Creation
+// timestamp unknown to the document is kept from the stored one.
+func (c *Collection) fullUpdate(ctx context.Context, session *mgo.Session, now time.Time) (interface{}, error) {
+	if c._createdAt.defined() && c.timeField(c._createdAt).IsZero() {
+		created, err := c.storedCreatedAt(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		if created.IsZero() {
+			created = now
+		}
+		c.setTimeField(c._createdAt, created)
+	}
+	c.touch(now)
+	return c._parent.Interface(), nil
+}
+
+// storedCreatedAt loads creation timestamp of the document from DB, zero
+// time is returned if document was not stored yet
+func (c *Collection) storedCreatedAt(ctx context.Context, session *mgo.Session) (created time.Time, err error) {
+	stored := bson.M{}
+	database, collection := c._collectionDB, c._collectionName
+	id, key := c.documentID(), c._createdAt.key
+	err = run(ctx, session, func(s *mgo.Session) error {
+		return s.DB(database).C(collection).FindId(id).Select(bson.M{key: 1}).One(&stored)
+	})
+	if err == mgo.ErrNotFound {
+		return created, nil
+	} else if err != nil {
+		return created, err
+	}
+	created, _ = stored[key].(time.Time)
+	return created, nil
+}
+
+// touch updates fields maintained by jc before document is written into DB
+func (c *Collection) touch(now time.Time) {
+	if c._updatedAt.defined() {
+		c.setTimeField(c._updatedAt, now)
+	}
+	if c._version.defined() {
+		c.setVersion(c.version() + 1)
+	}
+}
+
+// Insert stores document into DB as a new record. Unlike Save, which
+// overwrites existing record with the same ID, Insert fails with
+// ErrDuplicateKey if such document already exists.
+func (c *Collection) Insert(reuseSocket bool) error {
+	return c.InsertCtx(context.Background(), reuseSocket)
+}
+
+// InsertCtx is Insert bound by the context
+func (c *Collection) InsertCtx(ctx context.Context, reuseSocket bool) error {
+	var doc interface{}
+
+	err := c.beforeSave()
+	if err != nil {
+		return err
+	}
+
+	err = c.Validate()
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c._connection, reuseSocket)
+	if err != nil {
+		return err
+	}
 	defer session.Close()
 
+	now := timestamp()
+	if c._createdAt.defined() && c.timeField(c._createdAt).IsZero() {
+		c.setTimeField(c._createdAt, now)
+	}
+	if c._updatedAt.defined() {
+		c.setTimeField(c._updatedAt, now)
+	}
+
+	documentID := c.documentID()
 	if c._hasExplicitID {
-		documentID = c._parent.Elem().FieldByName(c._explicitIDField).Interface()
-	} else if len(c._implicitIDValue) > 0 {
-		documentID = c._implicitIDValue
+		doc = c._parent.Interface()
 	} else {
-		c._implicitIDValue = bson.NewObjectId()
-		documentID = c._implicitIDValue
+		// Documents with implicit ID don't carry '_id' field, it has to be added manually
+		fields, err := c.marshalFields()
+		if err != nil {
+			return err
+		}
+		doc = append(bson.D{{Name: "_id", Value: documentID}}, fields...)
+	}
+	doc, err = detached(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	database, collection := c._collectionDB, c._collectionName
+	err = run(ctx, session, func(s *mgo.Session) error {
+		return s.DB(database).C(collection).Insert(doc)
+	})
+	if mgo.IsDup(err) {
+		return ErrDuplicateKey
+	} else if err != nil {
+		return err
+	}
+
+	err = c.takeSnapshot()
+	if err != nil {
+		return err
+	}
+	return c.afterSave()
+}
+
+func (c *Collection) Delete(reuseSocket bool) error {
+	return c.DeleteCtx(context.Background(), reuseSocket)
+}
+
+// DeleteCtx is Delete bound by the context
+func (c *Collection) DeleteCtx(ctx context.Context, reuseSocket bool) error {
+	if !c._hasExplicitID && len(c._implicitIDValue) == 0 {
+		return ErrNotFound
+	}
+
+	err := c.beforeDelete()
+	if err != nil {
+		return err
+	}
+
+	session, err := getSession(c._connection, reuseSocket)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	now := timestamp()
+	id, soft := c.ID(), c._softDelete
+	database, collection := c._collectionDB, c._collectionName
+	err = run(ctx, session, func(s *mgo.Session) error {
+		return removeByID(s.DB(database).C(collection), id, soft, now)
+	})
+	if err == nil && c._softDelete {
+		c.markDeleted(now)
+	}
+	return err
+}
+
+// Reload replaces content of the document with its current state in DB.
+// Collection metadata (database, collection name, implicit ID) is preserved.
+func (c *Collection) Reload(reuseSocket bool) error {
+	return c.ReloadCtx(context.Background(), reuseSocket)
+}
+
+// ReloadCtx is Reload bound by the context
+func (c *Collection) ReloadCtx(ctx context.Context, reuseSocket bool) error {
+	if !c._hasExplicitID && len(c._implicitIDValue) == 0 {
+		return ErrNotFound
 	}
 
-	collection := session.DB(c._collectionDB).C(c._collectionName)
-	info, err = collection.Upsert(bson.M{idField: documentID}, c._parent.Interface())
+	session, err := getSession(c._connection, reuseSocket)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
 
-	return info, err
+	var raw bson.Raw
+	id := c.ID()
+	database, collection := c._collectionDB, c._collectionName
+	err = run(ctx, session, func(s *mgo.Session) error {
+		return s.DB(database).C(collection).FindId(id).One(&raw)
+	})
+	if err == mgo.ErrNotFound {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// Unmarshalling resets whole parent structure, including embedded Collection
+	metadata := *c
+	err = raw.Unmarshal(c._parent.Interface())
+	*c = metadata
+	if err != nil {
+		return err
+	}
+	c._omitted = nil
+
+	err = c.takeSnapshot()
+	if err != nil {
+		return err
+	}
+	return c.afterLoad()
 }
 
 func (c *Collection) ID() (id interface{}) {
@@ -104,6 +406,74 @@ func (c *Collection) ID() (id interface{}) {
 	return id
 }
 
+// documentID returns ID of the document. Documents with implicit ID get
+// new ID generated if they don't have one yet.
+func (c *Collection) documentID() interface{} {
+	if !c._hasExplicitID && len(c._implicitIDValue) == 0 {
+		c._implicitIDValue = bson.NewObjectId()
+	}
+	return c.ID()
+}
+
+// marshalFields returns parent structure as it would be stored in DB
+func (c *Collection) marshalFields() (fields bson.D, err error) {
+	err = c.marshalInto(&fields)
+	return
+}
+
+func (c *Collection) marshalInto(out interface{}) error {
+	data, err := bson.Marshal(c._parent.Interface())
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, out)
+}
+
+func (c *Collection) base() *Collection {
+	return c
+}
+
+// fieldByKey returns name of the field stored in DB under given key
+func (c *Collection) fieldByKey(key string) (string, bool) {
+	for name, fieldKey := range c._keys {
+		if fieldKey == key {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// bsonKey translates name of the field into key under which it's stored in DB.
+// Both struct field names and bson keys are accepted, as well as dotted paths
+// into embedded documents.
+func (c *Collection) bsonKey(name string) (string, error) {
+	path := strings.SplitN(name, ".", 2)
+	key, found := c._keys[path[0]]
+	if !found {
+		if _, known := c.fieldByKey(path[0]); !known && path[0] != "_id" {
+			return "", errors.New(fmt.Sprintf("unknown field '%s'", name))
+		}
+		key = path[0]
+	}
+	if len(path) > 1 {
+		key += "." + path[1]
+	}
+	return key, nil
+}
+
+// loaded is called on documents freshly fetched from DB
+func (c *Collection) loaded(id interface{}) error {
+	if objectID, ok := id.(bson.ObjectId); ok && !c._hasExplicitID {
+		c._implicitIDValue = objectID
+	}
+
+	err := c.takeSnapshot()
+	if err != nil {
+		return err
+	}
+	return c.afterLoad()
+}
+
 func (c *Collection) GetField(name string) (result interface{}, err error) {
 	if unicode.IsLower(rune(name[0])) {
 		err = errors.New(fmt.Sprintf("can't access unexported field '%s'", name))
@@ -119,24 +489,49 @@ func (c *Collection) GetField(name string) (result interface{}, err error) {
 	return
 }
 
+// Init binds Collection to its parent document. Model metadata parsed from
+// struct fields and their tags are cached per type, so that documents loaded
+// by queries don't parse them over and over.
 func (c *Collection) Init(parent reflect.Value, parentType reflect.Type) {
-
 	c._parent = parent
 	c._parentType = parentType
-	c._hasExplicitID = false
-	for i := 0; i < reflect.Indirect(c._parent).NumField(); i++ {
-		field := c._parentType.Field(i)
+
+	metadataLock.RLock()
+	metadata, cached := metadataCache[parentType]
+	metadataLock.RUnlock()
+	if !cached {
+		metadata = &Collection{_parentType: parentType}
+		metadata.parseModel()
+		metadataLock.Lock()
+		metadataCache[parentType] = metadata
+		metadataLock.Unlock()
+	}
+
+	c.copyMetadata(metadata)
+	c._initialized = true
+}
+
+// parseModel collects metadata of the model from fields of parent type
+func (c *Collection) parseModel() {
+	parentType := c._parentType
+	c._keys = make(map[string]string)
+	collectionOptions := make(map[string]string)
+	for i := 0; i < parentType.NumField(); i++ {
+		field := parentType.Field(i)
 
 		// Find explicit Collection name
 		if field.Type == reflect.TypeOf(Collection{}) {
 			explicitName := false
 			jc_tag, tag_present := field.Tag.Lookup("jc")
 			if tag_present {
-				jc_fields := strings.Split(jc_tag, ",")
+				jc_fields := strings.SplitN(jc_tag, ",", 2)
 				if len(jc_fields) > 0 && jc_fields[0] != "" {
 					c.setCollection(jc_fields[0])
 					explicitName = true
 				}
+				if len(jc_fields) > 1 {
+					collectionOptions = parseTagOptions(jc_fields[1])
+				}
 			}
 			if !explicitName {
 				c.setCollection(camelToSnake(parentType.Name()))
@@ -144,9 +539,13 @@ func (c *Collection) Init(parent reflect.Value, parentType reflect.Type) {
 		}
 
 		// Find explicit index field
+		key := strings.ToLower(field.Name)
 		bson_tag, tag_present := field.Tag.Lookup("bson")
 		if tag_present {
 			field_id := strings.Split(bson_tag, ",")
+			if field_id[0] != "" {
+				key = field_id[0]
+			}
 			switch field_id[0] {
 			case "_id":
 				c._explicitIDField = field.Name
@@ -158,23 +557,59 @@ func (c *Collection) Init(parent reflect.Value, parentType reflect.Type) {
 				break
 			}
 		}
+		if field.PkgPath == "" && field.Type != reflect.TypeOf(Collection{}) {
+			c._keys[field.Name] = key
+			c.parseFieldTag(field, key)
+		}
 		c._skeleton = append(c._skeleton, field)
 	}
-	c._initialized = true
+	// Collection options can refer to fields, so they are processed once all fields are known
+	c.parseCollectionOptions(collectionOptions)
+}
 
+// copyMetadata shares metadata of the model parsed by parseModel
+func (c *Collection) copyMetadata(metadata *Collection) {
+	c._collectionName = metadata._collectionName
+	c._hasExplicitID = metadata._hasExplicitID
+	c._explicitIDField = metadata._explicitIDField
+	c._skeleton = metadata._skeleton
+	c._keys = metadata._keys
+	c._rules = metadata._rules
+	c._tagError = metadata._tagError
+	c._createdAt = metadata._createdAt
+	c._updatedAt = metadata._updatedAt
+	c._version = metadata._version
+	c._softDelete = metadata._softDelete
+	c._indexes = metadata._indexes
+	c._connection = metadata._connection
+	c._explicitDB = metadata._explicitDB
+	c._readMode = metadata._readMode
 }
 
+// InitDB sets database of the document. Unless the model specifies database
+// with 'db' option of Collection tag, default database of the session is used.
 func (c *Collection) InitDB() error {
-	session, err := tools.GetSessionClone()
+	session, err := tools.GetNamedSessionClone(c._connection)
 	if err == nil {
-		c.SetDatabase(session.DB("").Name)
+		c.SetDatabase(session.DB(c._explicitDB).Name)
 		defer session.Close()
+	} else if c._connection != "" {
+		err = errors.New(fmt.Sprintf("connection '%s' not initialized", c._connection))
 	} else {
 		err = errors.New("database not initialized")
 	}
 	return err
 }
 
+// InitDBCtx is InitDB bound by the context
+func (c *Collection) InitDBCtx(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	return c.InitDB()
+}
+
 func (c *Collection) NewImplicitID() (err error) {
 
 	if c._hasExplicitID {
@@ -194,6 +629,86 @@ func NewDocument(c document) error {
 	return err
 }
 
+// DeleteByID removes document with given ID from collection of supplied prototype.
+// Prototype is initialized automatically if needed. If prototype implements
+// BeforeDeleter, document is loaded into it first, so that the hook can
+// inspect its content.
+func DeleteByID(prototype document, id interface{}) error {
+	return DeleteByIDCtx(context.Background(), prototype, id)
+}
+
+// DeleteByIDCtx is DeleteByID bound by the context
+func DeleteByIDCtx(ctx context.Context, prototype document, id interface{}) error {
+	if !prototype.IsInitialized() {
+		err := NewDocument(prototype)
+		if err != nil {
+			return err
+		}
+	}
+
+	session, err := getSession(prototype.base()._connection, true)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	database, collection := prototype.Database(), prototype.CollectionName()
+
+	if _, hooked := prototype.(BeforeDeleter); hooked {
+		var raw bson.Raw
+		err = run(ctx, session, func(s *mgo.Session) error {
+			return s.DB(database).C(collection).FindId(id).One(&raw)
+		})
+		if err == mgo.ErrNotFound {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		err = loadDocument(raw, reflect.ValueOf(prototype), reflect.TypeOf(prototype).Elem(), nil)
+		if err != nil {
+			return err
+		}
+		prototype.SetDatabase(database)
+		return prototype.DeleteCtx(ctx, true)
+	}
+
+	soft, now := prototype.base()._softDelete, timestamp()
+	return run(ctx, session, func(s *mgo.Session) error {
+		return removeByID(s.DB(database).C(collection), id, soft, now)
+	})
+}
+
+// getSession returns clone or copy of named session, empty connection name
+// refers to master session.
+func getSession(connection string, reuseSocket bool) (*mgo.Session, error) {
+	if reuseSocket {
+		return tools.GetNamedSessionClone(connection)
+	}
+	return tools.GetNamedSessionCopy(connection)
+}
+
+// loadDocument fills target document with raw data fetched from DB and initializes it.
+// Keys listed in omitted were excluded from the fetched data by projection.
+func loadDocument(raw bson.Raw, target reflect.Value, targetType reflect.Type, omitted map[string]bool) error {
+	var id struct {
+		ID interface{} `bson:"_id"`
+	}
+
+	err := raw.Unmarshal(target.Interface())
+	if err != nil {
+		return err
+	}
+	initPrototype(target, targetType)
+
+	err = raw.Unmarshal(&id)
+	if err != nil {
+		return err
+	}
+	doc := target.Interface().(document)
+	doc.base()._omitted = omitted
+	return doc.loaded(id.ID)
+}
+
 func camelToSnake(camel string) string {
 	var (
 		snake_name []rune
@@ -0,0 +1,100 @@
+package jc
+
+import (
+	"context"
+	"time"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DetachedTimeout is socket timeout of operation bound by context that can be
+// cancelled but has no deadline. Such operation keeps running in background
+// after cancellation, so it must not wait for the server forever.
+const DetachedTimeout = time.Minute
+
+// run performs DB operation bound by the context. Deadline of the context is
+// applied as timeout of the session. Operation bound by context that can be
+// cancelled runs in separate goroutine on its own clone of the session, so that
+// run returns as soon as the context is done. Such operation must not share
+// any state with the caller until it finishes.
+func run(ctx context.Context, session *mgo.Session, operation func(*mgo.Session) error) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	applyDeadline(ctx, session)
+	if ctx.Done() == nil {
+		return operation(session)
+	}
+
+	clone := session.Clone()
+	if _, bounded := ctx.Deadline(); !bounded {
+		clone.SetSocketTimeout(DetachedTimeout)
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer clone.Close()
+		done <- operation(clone)
+	}()
+
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// applyDeadline limits timeouts of the session by deadline of the context
+func applyDeadline(ctx context.Context, session *mgo.Session) {
+	if deadline, bounded := ctx.Deadline(); bounded {
+		timeout := time.Until(deadline)
+		session.SetSyncTimeout(timeout)
+		session.SetSocketTimeout(timeout)
+	}
+}
+
+// detached returns document marshalled in advance if the operation can outlive
+// its caller, so that the document is not read after the caller returns.
+func detached(ctx context.Context, doc interface{}) (interface{}, error) {
+	if ctx.Done() == nil || doc == nil {
+		return doc, nil
+	}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return bson.Raw{Kind: 0x03, Data: data}, nil
+}
+
+// withMaxTime limits server side execution time of the query by deadline of the context
+func withMaxTime(ctx context.Context, query *mgo.Query) (*mgo.Query, error) {
+	remaining, err := remainingTime(ctx)
+	if err == nil && remaining > 0 {
+		query = query.SetMaxTime(remaining)
+	}
+	return query, err
+}
+
+// remainingTime returns time left until deadline of the context, or zero if
+// there is no deadline. It is at least a millisecond, as server treats zero
+// time limit as no limit at all.
+func remainingTime(ctx context.Context) (time.Duration, error) {
+	deadline, bounded := ctx.Deadline()
+	if !bounded {
+		return 0, nil
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return 0, context.DeadlineExceeded
+	}
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+	return remaining, nil
+}
@@ -0,0 +1,146 @@
+package jc
+
+import (
+	"reflect"
+	"sort"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// IsDirty reports whether document differs from its last known state in DB.
+// Documents that were never loaded from or saved into DB are always dirty.
+func (c *Collection) IsDirty() bool {
+	if !c.hasSnapshot() {
+		return true
+	}
+	set, unset, err := c.diff()
+	return err != nil || len(set)+len(unset) > 0
+}
+
+// ChangedFields returns names of fields that changed since document was
+// last loaded from or saved into DB.
+func (c *Collection) ChangedFields() (fields []string) {
+	if !c.hasSnapshot() {
+		for _, field := range c._skeleton {
+			if _, exported := c._keys[field.Name]; exported {
+				fields = append(fields, field.Name)
+			}
+		}
+		return fields
+	}
+
+	set, unset, err := c.diff()
+	if err != nil {
+		return fields
+	}
+
+	changed := make(map[string]bool)
+	for key := range set {
+		changed[key] = true
+	}
+	for key := range unset {
+		changed[key] = true
+	}
+
+	for _, field := range c._skeleton {
+		key, exported := c._keys[field.Name]
+		if exported && changed[key] {
+			fields = append(fields, field.Name)
+			delete(changed, key)
+		}
+	}
+
+	// Keys that don't map directly to struct fields (e.g. inlined structures)
+	var remaining []string
+	for key := range changed {
+		remaining = append(remaining, key)
+	}
+	sort.Strings(remaining)
+	return append(fields, remaining...)
+}
+
+func (c *Collection) takeSnapshot() error {
+	snapshot := bson.M{}
+	err := c.marshalInto(&snapshot)
+	if err != nil {
+		return err
+	}
+	c._snapshot = snapshot
+	c._snapshotID = c.ID()
+	return nil
+}
+
+// hasSnapshot reports whether there is known state of the document in DB.
+// Changing document ID invalidates snapshot.
+func (c *Collection) hasSnapshot() bool {
+	return c._snapshot != nil && reflect.DeepEqual(c._snapshotID, c.ID())
+}
+
+// IsPartial reports whether document was loaded only partially by query with
+// projection. Fields that were not loaded are never written by Save.
+func (c *Collection) IsPartial() bool {
+	return len(c._omitted) > 0
+}
+
+// diff compares current document state with its snapshot. Fields that were
+// not loaded from DB are ignored.
+func (c *Collection) diff() (set bson.M, unset bson.M, err error) {
+	current := bson.M{}
+	err = c.marshalInto(&current)
+	if err != nil {
+		return
+	}
+
+	set = bson.M{}
+	unset = bson.M{}
+	for key, value := range current {
+		if key == "_id" || c._omitted[key] {
+			continue
+		}
+		original, found := c._snapshot[key]
+		if !found || !reflect.DeepEqual(original, value) {
+			set[key] = value
+		}
+	}
+	for key := range c._snapshot {
+		if _, found := current[key]; !found && key != "_id" && !c._omitted[key] {
+			unset[key] = ""
+		}
+	}
+	return
+}
+
+// changes returns update operators that bring document in DB to its current state
+func (c *Collection) changes() (update bson.M, err error) {
+	set, unset, err := c.diff()
+	if err != nil {
+		return
+	}
+
+	update = bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return
+}
+
+// maintainedKeys returns keys of fields that jc updates on every save
+func (c *Collection) maintainedKeys() (keys []string) {
+	for _, ref := range []fieldRef{c._version, c._updatedAt} {
+		if ref.defined() {
+			keys = append(keys, ref.key)
+		}
+	}
+	return keys
+}
+
+func (c *Collection) isMaintained(key string) bool {
+	for _, maintained := range c.maintainedKeys() {
+		if key == maintained {
+			return true
+		}
+	}
+	return false
+}
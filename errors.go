@@ -0,0 +1,11 @@
+package jc
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound      = errors.New("document not found")
+	ErrDuplicateKey  = errors.New("document with the same key already exists")
+	ErrStaleDocument = errors.New("document was modified since it was loaded")
+)
@@ -0,0 +1,178 @@
+package jc
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Condition is composable part of query filter. Conditions refer to fields by
+// struct field names or bson names, struct field names are translated to bson
+// names once condition is passed to Query.Filter.
+type Condition interface {
+	build(resolve resolver) (interface{}, error)
+}
+
+// resolver translates field name into bson key
+type resolver func(string) (string, error)
+
+type fieldCondition struct {
+	field    string
+	operator string
+	value    interface{}
+}
+
+type logicalCondition struct {
+	operator   string
+	conditions []Condition
+}
+
+type elemMatchCondition struct {
+	field      string
+	conditions []Condition
+}
+
+type rawCondition struct {
+	filter interface{}
+}
+
+// Eq matches documents where field equals value
+func Eq(field string, value interface{}) Condition {
+	return fieldCondition{field, "$eq", value}
+}
+
+// Ne matches documents where field does not equal value
+func Ne(field string, value interface{}) Condition {
+	return fieldCondition{field, "$ne", value}
+}
+
+func Gt(field string, value interface{}) Condition {
+	return fieldCondition{field, "$gt", value}
+}
+
+func Gte(field string, value interface{}) Condition {
+	return fieldCondition{field, "$gte", value}
+}
+
+func Lt(field string, value interface{}) Condition {
+	return fieldCondition{field, "$lt", value}
+}
+
+func Lte(field string, value interface{}) Condition {
+	return fieldCondition{field, "$lte", value}
+}
+
+// In matches documents where field equals any of the values
+func In(field string, values ...interface{}) Condition {
+	return fieldCondition{field, "$in", values}
+}
+
+// Nin matches documents where field equals none of the values
+func Nin(field string, values ...interface{}) Condition {
+	return fieldCondition{field, "$nin", values}
+}
+
+// Exists matches documents that contain (or don't contain) the field
+func Exists(field string, exists bool) Condition {
+	return fieldCondition{field, "$exists", exists}
+}
+
+// Regex matches documents where field matches the pattern. Options are the
+// same as in MongoDB's $options (e.g. "i" for case insensitive match).
+func Regex(field string, pattern string, options string) Condition {
+	return fieldCondition{field, "", bson.RegEx{Pattern: pattern, Options: options}}
+}
+
+// ElemMatch matches documents where at least one element of array field
+// satisfies all conditions. Fields in conditions refer to array elements and
+// are used as they are.
+func ElemMatch(field string, conditions ...Condition) Condition {
+	return elemMatchCondition{field, conditions}
+}
+
+// And matches documents that satisfy all conditions
+func And(conditions ...Condition) Condition {
+	return logicalCondition{"$and", conditions}
+}
+
+// Or matches documents that satisfy at least one of conditions
+func Or(conditions ...Condition) Condition {
+	return logicalCondition{"$or", conditions}
+}
+
+// Nor matches documents that satisfy none of conditions
+func Nor(conditions ...Condition) Condition {
+	return logicalCondition{"$nor", conditions}
+}
+
+// Not matches documents that don't satisfy the condition
+func Not(condition Condition) Condition {
+	return logicalCondition{"$nor", []Condition{condition}}
+}
+
+// Raw turns plain filter (map or struct) into Condition, so that it can be
+// combined with other conditions. Raw filter is used as it is.
+func Raw(filter interface{}) Condition {
+	return rawCondition{filter}
+}
+
+func (c fieldCondition) build(resolve resolver) (interface{}, error) {
+	key, err := resolve(c.field)
+	if err != nil {
+		return nil, err
+	}
+	if c.operator == "" {
+		return bson.M{key: c.value}, nil
+	}
+	return bson.M{key: bson.M{c.operator: c.value}}, nil
+}
+
+func (c logicalCondition) build(resolve resolver) (interface{}, error) {
+	if len(c.conditions) == 0 {
+		// Server rejects empty '$and', '$or' and '$nor' arrays
+		return nil, errors.New(fmt.Sprintf("'%s' requires at least one condition", c.operator))
+	}
+	built, err := buildAll(c.conditions, resolve)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{c.operator: built}, nil
+}
+
+func (c elemMatchCondition) build(resolve resolver) (interface{}, error) {
+	key, err := resolve(c.field)
+	if err != nil {
+		return nil, err
+	}
+	built, err := buildAll(c.conditions, verbatim)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{}
+	if len(built) > 0 {
+		match["$and"] = built
+	}
+	return bson.M{key: bson.M{"$elemMatch": match}}, nil
+}
+
+func (c rawCondition) build(resolve resolver) (interface{}, error) {
+	return c.filter, nil
+}
+
+func buildAll(conditions []Condition, resolve resolver) (built []interface{}, err error) {
+	for _, condition := range conditions {
+		var part interface{}
+		part, err = condition.build(resolve)
+		if err != nil {
+			return
+		}
+		built = append(built, part)
+	}
+	return
+}
+
+// verbatim resolver uses field names as they are
+func verbatim(field string) (string, error) {
+	return field, nil
+}
@@ -0,0 +1,50 @@
+package jc
+
+// Models can implement any of following interfaces to react to persistence
+// events. Error returned from Before* hook aborts the operation, error
+// returned from After* hook is passed to the caller.
+type (
+	BeforeSaver interface {
+		BeforeSave() error
+	}
+
+	AfterSaver interface {
+		AfterSave() error
+	}
+
+	AfterLoader interface {
+		AfterLoad() error
+	}
+
+	BeforeDeleter interface {
+		BeforeDelete() error
+	}
+)
+
+func (c *Collection) beforeSave() error {
+	if hook, ok := c._parent.Interface().(BeforeSaver); ok {
+		return hook.BeforeSave()
+	}
+	return nil
+}
+
+func (c *Collection) afterSave() error {
+	if hook, ok := c._parent.Interface().(AfterSaver); ok {
+		return hook.AfterSave()
+	}
+	return nil
+}
+
+func (c *Collection) afterLoad() error {
+	if hook, ok := c._parent.Interface().(AfterLoader); ok {
+		return hook.AfterLoad()
+	}
+	return nil
+}
+
+func (c *Collection) beforeDelete() error {
+	if hook, ok := c._parent.Interface().(BeforeDeleter); ok {
+		return hook.BeforeDelete()
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package jc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"gopkg.in/mgo.v2"
+)
+
+// IndexReport describes index declared by the model
+type IndexReport struct {
+	Database   string
+	Collection string
+	Key        []string
+	Unique     bool
+	Created    bool
+}
+
+// EnsureIndexes creates indexes declared in 'jc' tags of supplied models.
+// Models that are not initialized are initialized automatically. Returned
+// report lists every declared index and whether it had to be created.
+func EnsureIndexes(models ...document) ([]IndexReport, error) {
+	return EnsureIndexesCtx(context.Background(), models...)
+}
+
+// EnsureIndexesCtx is EnsureIndexes bound by the context
+func EnsureIndexesCtx(ctx context.Context, models ...document) (report []IndexReport, err error) {
+	for _, model := range models {
+		if !model.IsInitialized() {
+			err = NewDocument(model)
+			if err != nil {
+				return
+			}
+		}
+		c := model.base()
+		if c._tagError != nil {
+			return report, c._tagError
+		}
+
+		var created []IndexReport
+		created, err = c.ensureIndexes(ctx)
+		if err != nil {
+			return
+		}
+		report = append(report, created...)
+	}
+	return
+}
+
+// ensureIndexes creates indexes declared by the model using its session
+func (c *Collection) ensureIndexes(ctx context.Context) ([]IndexReport, error) {
+	session, err := getSession(c._connection, true)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var report []IndexReport
+	database, name, indexes := c._collectionDB, c._collectionName, c._indexes
+	err = run(ctx, session, func(s *mgo.Session) error {
+		collection := s.DB(database).C(name)
+		// Listing indexes of collection that does not exist yet fails, there are no indexes in such case
+		existing, err := collection.Indexes()
+		if err != nil && !isNamespaceNotFound(err) {
+			return err
+		}
+		// mgo remembers indexes it ensured, even if their collection was dropped since then
+		s.ResetIndexCache()
+
+		for _, index := range indexes {
+			err = collection.EnsureIndex(index)
+			if err != nil {
+				return err
+			}
+			report = append(report, IndexReport{
+				Database:   database,
+				Collection: name,
+				Key:        index.Key,
+				Unique:     index.Unique,
+				Created:    !hasIndex(existing, index.Key),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// isNamespaceNotFound reports whether err means that collection does not exist
+func isNamespaceNotFound(err error) bool {
+	if queryErr, ok := err.(*mgo.QueryError); ok {
+		return queryErr.Code == 26 || queryErr.Message == "ns does not exist"
+	}
+	return false
+}
+
+func hasIndex(indexes []mgo.Index, key []string) bool {
+	for _, index := range indexes {
+		if reflect.DeepEqual(index.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIndexTag recognizes 'index', 'unique' and 'ttl' options of the field
+func (c *Collection) parseIndexTag(field reflect.StructField, key string, options map[string]string) error {
+	order, indexed := options["index"]
+	_, unique := options["unique"]
+	ttl, expiring := options["ttl"]
+	if !indexed && !unique && !expiring {
+		return nil
+	}
+
+	index := mgo.Index{Key: []string{key}, Unique: unique}
+	switch order {
+	case "", "1":
+	case "-1":
+		index.Key = []string{"-" + key}
+	default:
+		return errors.New(fmt.Sprintf("invalid index order '%s' on field '%s'", order, field.Name))
+	}
+
+	if expiring {
+		seconds, err := strconv.Atoi(ttl)
+		if err != nil || seconds <= 0 {
+			return errors.New(fmt.Sprintf("invalid 'ttl' on field '%s'", field.Name))
+		}
+		index.ExpireAfter = time.Duration(seconds) * time.Second
+	}
+
+	c._indexes = append(c._indexes, index)
+	return nil
+}
+
+// parseCompoundIndexes processes compound index declarations from 'jc' tag of
+// embedded Collection. Fields of the index are separated by '|' and multiple
+// indexes by ';', e.g. 'index=LastName|-FirstName;City|Street'.
+func (c *Collection) parseCompoundIndexes(declaration string, unique bool) error {
+	for _, spec := range strings.Split(declaration, ";") {
+		if spec == "" {
+			continue
+		}
+		index := mgo.Index{Unique: unique}
+		for _, name := range strings.Split(spec, "|") {
+			prefix := ""
+			if strings.HasPrefix(name, "-") {
+				prefix, name = "-", name[1:]
+			}
+			key, err := c.bsonKey(name)
+			if err != nil {
+				return err
+			}
+			index.Key = append(index.Key, prefix+key)
+		}
+		c._indexes = append(c._indexes, index)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package jc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Iter streams documents matching the query one by one, without loading
+// whole result into memory. Iter must be closed after use.
+type Iter struct {
+	ctx        context.Context
+	session    *mgo.Session
+	iter       *mgo.Iter
+	resultType reflect.Type
+	omitted    map[string]bool
+	err        error
+}
+
+// Iter executes query and returns iterator over its result. Query target is
+// used only to determine model of the documents and is not filled.
+func (q *Query) Iter(reuseSocket bool) (*Iter, error) {
+	return q.IterCtx(context.Background(), reuseSocket)
+}
+
+// IterCtx is Iter bound by the context. Deadline of the context limits
+// timeouts of the session and execution time of the query on the server.
+// Once the context is done, iteration stops with context error.
+func (q *Query) IterCtx(ctx context.Context, reuseSocket bool) (*Iter, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	session, err := q.readSession(reuseSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	applyDeadline(ctx, session)
+
+	query, err := withMaxTime(ctx, q.prepare(session))
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if q.batch > 0 {
+		query = query.Batch(q.batch)
+	}
+
+	return &Iter{
+		ctx:        ctx,
+		session:    session,
+		iter:       query.Iter(),
+		resultType: q.resultType,
+		omitted:    q.omitted,
+	}, nil
+}
+
+// ForEach calls fn for every document matching the query. Documents are passed
+// as pointers to the query model. Error returned by fn stops the iteration.
+func (q *Query) ForEach(reuseSocket bool, fn func(doc interface{}) error) error {
+	return q.ForEachCtx(context.Background(), reuseSocket, fn)
+}
+
+// ForEachCtx is ForEach bound by the context
+func (q *Query) ForEachCtx(ctx context.Context, reuseSocket bool, fn func(doc interface{}) error) error {
+	iter, err := q.IterCtx(ctx, reuseSocket)
+	if err != nil {
+		return err
+	}
+
+	for {
+		doc := reflect.New(q.resultType).Interface()
+		if !iter.Next(doc) {
+			break
+		}
+		err = fn(doc)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+// Batch sets number of documents fetched from DB in one round trip while iterating
+func (q *Query) Batch(size int) *Query {
+	q.batch = size
+	return q
+}
+
+func (q *Query) GetBatch() int {
+	return q.batch
+}
+
+// Next loads next document into doc, which must be pointer to the query model.
+// It returns false when there are no more documents or an error occurred.
+func (i *Iter) Next(doc interface{}) bool {
+	if i.err == nil {
+		i.err = i.ctx.Err()
+	}
+	if i.err != nil {
+		return false
+	}
+
+	target := reflect.ValueOf(doc)
+	expected := reflect.PtrTo(i.resultType)
+	if !target.IsValid() || target.Type() == expected && target.IsNil() {
+		i.err = errors.New(fmt.Sprintf("expected '%s', got nil", expected))
+		return false
+	} else if target.Type() != expected {
+		i.err = errors.New(fmt.Sprintf("expected '%s', got '%s'", expected, target.Type()))
+		return false
+	}
+
+	var raw bson.Raw
+	if !i.iter.Next(&raw) {
+		return false
+	}
+
+	i.err = loadDocument(raw, target, i.resultType, i.omitted)
+	return i.err == nil
+}
+
+// Err returns error that stopped the iteration, if any
+func (i *Iter) Err() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.iter.Err()
+}
+
+// Close releases resources held by iterator. It returns error that stopped
+// the iteration, if any.
+func (i *Iter) Close() error {
+	err := i.iter.Close()
+	i.session.Close()
+	if i.err != nil {
+		return i.err
+	}
+	return err
+}
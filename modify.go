@@ -0,0 +1,68 @@
+package jc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Change describes modification applied by Query.FindAndModify. It's just
+// convenient alias to mgo.Change.
+type Change mgo.Change
+
+// FindAndModify atomically modifies first document matching the query (with
+// sort applied) and loads it into query target. Depending on change.ReturnNew,
+// target holds document as it was before or after the modification. Removing
+// document of model with soft delete only marks it as deleted. It returns
+// ErrNotFound if no document matched and no document was upserted.
+func (q *Query) FindAndModify(reuseSocket bool, change Change) (*mgo.ChangeInfo, error) {
+	return q.FindAndModifyCtx(context.Background(), reuseSocket, change)
+}
+
+// FindAndModifyCtx is FindAndModify bound by the context
+func (q *Query) FindAndModifyCtx(ctx context.Context, reuseSocket bool, change Change) (info *mgo.ChangeInfo, err error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if !q.singleValue {
+		return nil, errors.New("FindAndModify requires query with single document target")
+	}
+
+	session, err := getSession(q.model._connection, reuseSocket)
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	if change.Remove && q.model._softDelete {
+		change.Remove = false
+		change.Update = bson.M{"$set": bson.M{deletedAtKey: timestamp()}}
+	}
+	change.Update, err = detached(ctx, change.Update)
+	if err != nil {
+		return
+	}
+
+	var raw bson.Raw
+	var applied *mgo.ChangeInfo
+	query := *q
+	err = run(ctx, session, func(s *mgo.Session) (err error) {
+		applied, err = query.prepare(s).Apply(mgo.Change(change), &raw)
+		return
+	})
+	if err == mgo.ErrNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return
+	}
+	info = applied
+
+	// Upsert without ReturnNew does not return any document
+	if raw.Data == nil {
+		return
+	}
+	err = loadDocument(raw, reflect.ValueOf(q.result), q.resultType, q.omitted)
+	return
+}
@@ -0,0 +1,204 @@
+package jc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// cursor holds position in paginated result. Keys are sort keys of the query
+// (including '_id' as a tiebreaker), values are the keys of the last document
+// on the page.
+type cursor struct {
+	Keys   []string      `bson:"k"`
+	Values []interface{} `bson:"v"`
+}
+
+// Page makes Execute load result in pages of given size, ordered by sort keys
+// of the query and document ID. Pagination uses ranges on these keys instead
+// of Skip, so deep pages are as fast as the first one. Limit and Skip are
+// ignored for paginated queries.
+func (q *Query) Page(size int) *Query {
+	q.pageSize = size
+	return q
+}
+
+// After makes Execute load page that follows the one cursor was obtained for.
+// Empty cursor means first page.
+func (q *Query) After(token string) *Query {
+	q.after = nil
+	if token == "" {
+		return q
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		q.after = &cursor{}
+		err = bson.Unmarshal(data, q.after)
+	}
+	if err != nil || len(q.after.Values) != len(q.after.Keys) {
+		q.after = nil
+		q.setErr(errors.New("invalid pagination cursor"))
+	}
+	return q
+}
+
+// NextCursor returns opaque URL safe cursor pointing after the last page loaded
+// by Execute. Empty cursor means there are no more pages.
+func (q *Query) NextCursor() string {
+	return q.nextCursor
+}
+
+func (q *Query) GetPage() int {
+	return q.pageSize
+}
+
+// pageKeys returns sort keys of paginated query, '_id' is always the last one
+func (q *Query) pageKeys() []string {
+	for _, key := range q.sort {
+		if strings.TrimPrefix(key, "-") == "_id" {
+			return q.sort
+		}
+	}
+	return append(append([]string{}, q.sort...), "_id")
+}
+
+func (q *Query) executePage(ctx context.Context, session *mgo.Session) error {
+	if q.singleValue {
+		return errors.New("paginated query requires slice target")
+	}
+
+	keys := q.pageKeys()
+	selector := q.selector()
+	if q.after != nil {
+		if !reflect.DeepEqual(q.after.Keys, keys) {
+			return errors.New("pagination cursor does not match query sort")
+		}
+		following := keysetCondition(keys, q.after.Values)
+		if selector == nil {
+			selector = following
+		} else {
+			selector = bson.M{"$and": []interface{}{selector, following}}
+		}
+	}
+
+	var projection bson.M
+	if q.projection != nil {
+		projection = pageProjection(q.projection, keys)
+	}
+
+	var raws []bson.Raw
+	database, collection, limit := q.Database, q.collection, q.pageSize+1
+	err := run(ctx, session, func(s *mgo.Session) error {
+		// One extra document tells whether there is another page
+		query := s.DB(database).C(collection).Find(selector).Sort(keys...).Limit(limit)
+		if projection != nil {
+			query = query.Select(projection)
+		}
+		query, err := withMaxTime(ctx, query)
+		if err != nil {
+			return err
+		}
+		return query.All(&raws)
+	})
+	if err != nil {
+		return err
+	}
+
+	q.nextCursor = ""
+	if len(raws) > q.pageSize {
+		raws = raws[:q.pageSize]
+		q.nextCursor, err = encodeCursor(keys, raws[len(raws)-1])
+		if err != nil {
+			return err
+		}
+	}
+	return loadSlice(raws, q.result, q.resultType, q.omitted)
+}
+
+// keysetCondition matches documents that follow given values in sort order.
+// Missing and null values sort before any other value, so they need special
+// treatment in both directions.
+func keysetCondition(keys []string, values []interface{}) bson.M {
+	var alternatives []interface{}
+	for i, key := range keys {
+		descending := strings.HasPrefix(key, "-")
+		key = strings.TrimPrefix(key, "-")
+		follow := func(condition interface{}) {
+			alternative := bson.M{key: condition}
+			for j := 0; j < i; j++ {
+				alternative[strings.TrimPrefix(keys[j], "-")] = values[j]
+			}
+			alternatives = append(alternatives, alternative)
+		}
+
+		switch {
+		case values[i] == nil && descending:
+			// nothing follows null, continue with the next key
+		case values[i] == nil:
+			follow(bson.M{"$ne": nil})
+		case descending:
+			follow(bson.M{"$lt": values[i]})
+			follow(nil)
+		default:
+			follow(bson.M{"$gt": values[i]})
+		}
+	}
+	return bson.M{"$or": alternatives}
+}
+
+// pageProjection makes sure that sort keys are loaded, as they are needed for cursor
+func pageProjection(projection bson.M, keys []string) bson.M {
+	result := bson.M{}
+	including := false
+	for key, value := range projection {
+		result[key] = value
+		including = including || value == 1
+	}
+	for _, key := range keys {
+		key = strings.TrimPrefix(key, "-")
+		if including {
+			result[key] = 1
+		} else {
+			delete(result, key)
+		}
+	}
+	return result
+}
+
+func encodeCursor(keys []string, raw bson.Raw) (string, error) {
+	document := bson.M{}
+	err := raw.Unmarshal(&document)
+	if err != nil {
+		return "", err
+	}
+
+	position := cursor{Keys: keys}
+	for _, key := range keys {
+		position.Values = append(position.Values, lookupKey(document, strings.TrimPrefix(key, "-")))
+	}
+
+	data, err := bson.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// lookupKey returns value stored under dotted key in the document
+func lookupKey(document bson.M, key string) interface{} {
+	path := strings.Split(key, ".")
+	var value interface{} = document
+	for _, part := range path {
+		embedded, ok := value.(bson.M)
+		if !ok {
+			return nil
+		}
+		value = embedded[part]
+	}
+	return value
+}
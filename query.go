@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"reflect"
 	"errors"
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2"
-	"github.com/kalcok/jc/tools"
+	"strings"
+	"context"
+	"time"
 )
 
 type Query struct {
@@ -17,6 +20,17 @@ type Query struct {
 	singleValue bool
 	result      interface{}
 	resultType  reflect.Type
+	model       *Collection
+	withDeleted bool
+	sort        []string
+	projection  bson.M
+	omitted     map[string]bool
+	batch       int
+	pageSize    int
+	after       *cursor
+	nextCursor  string
+	readMode    *mgo.Mode
+	err         error
 }
 
 func NewQuery(result interface{}) (newQuery Query, err error) {
@@ -44,55 +58,235 @@ func NewQuery(result interface{}) (newQuery Query, err error) {
 
 	newQuery.collection = proto_val.FieldByName("_collectionName").String()
 	newQuery.Database = proto_val.FieldByName("_collectionDB").String()
+	newQuery.model = prototype.Interface().(document).base()
+	newQuery.readMode = newQuery.model._readMode
+	newQuery.err = newQuery.model._tagError
 
 	return
 }
 
-func (q *Query) Execute(reuseSocket bool) (err error) {
-	var session *mgo.Session
+func (q *Query) Execute(reuseSocket bool) error {
+	return q.ExecuteCtx(context.Background(), reuseSocket)
+}
 
-	if reuseSocket {
-		session, err = tools.GetSessionClone()
-	} else {
-		session, err = tools.GetSessionCopy()
+// ExecuteCtx is Execute bound by the context. Deadline of the context also
+// limits execution time of the query on the server.
+func (q *Query) ExecuteCtx(ctx context.Context, reuseSocket bool) (err error) {
+	if q.err != nil {
+		return q.err
 	}
+
+	session, err := q.readSession(reuseSocket)
 	if err != nil {
 		return
 	}
 	defer session.Close()
 
-	query := session.DB(q.Database).C(q.collection).Find(q.filter)
+	if q.pageSize > 0 {
+		return q.executePage(ctx, session)
+	}
 
-	if q.skip > 0 {
-		query = query.Skip(q.skip)
+	query := *q
+	if q.singleValue {
+		var raw bson.Raw
+		err = run(ctx, session, func(s *mgo.Session) error {
+			prepared, err := withMaxTime(ctx, query.prepare(s))
+			if err != nil {
+				return err
+			}
+			return prepared.One(&raw)
+		})
+		if err != nil {
+			return
+		}
+		err = loadDocument(raw, reflect.ValueOf(q.result), q.resultType, q.omitted)
+	} else {
+		var raws []bson.Raw
+		err = run(ctx, session, func(s *mgo.Session) error {
+			prepared, err := withMaxTime(ctx, query.prepare(s))
+			if err != nil {
+				return err
+			}
+			return prepared.All(&raws)
+		})
+		if err != nil {
+			return
+		}
+		err = loadSlice(raws, q.result, q.resultType, q.omitted)
 	}
+	return
+}
 
-	if q.limit > 0 {
-		query = query.Limit(q.limit)
+// Count returns number of documents matching the query, with skip and limit applied
+func (q *Query) Count(reuseSocket bool) (int, error) {
+	return q.CountCtx(context.Background(), reuseSocket)
+}
+
+// CountCtx is Count bound by the context
+func (q *Query) CountCtx(ctx context.Context, reuseSocket bool) (count int, err error) {
+	if q.err != nil {
+		return 0, q.err
 	}
 
-	if q.singleValue {
-		err = query.One(q.result)
-	} else {
-		err = query.All(q.result)
+	session, err := q.readSession(reuseSocket)
+	if err != nil {
+		return
 	}
+	defer session.Close()
 
+	var counted int
+	query := *q
+	err = run(ctx, session, func(s *mgo.Session) (err error) {
+		counted, err = query.prepare(s).Count()
+		return
+	})
 	if err != nil {
 		return
 	}
-	q.initResult()
-	return
+	return counted, nil
+}
+
+// Exists reports whether there is at least one document matching the query
+func (q *Query) Exists(reuseSocket bool) (bool, error) {
+	return q.ExistsCtx(context.Background(), reuseSocket)
+}
+
+// ExistsCtx is Exists bound by the context
+func (q *Query) ExistsCtx(ctx context.Context, reuseSocket bool) (bool, error) {
+	if q.err != nil {
+		return false, q.err
+	}
+
+	session, err := q.readSession(reuseSocket)
+	if err != nil {
+		return false, err
+	}
+	defer session.Close()
+
+	var count int
+	query := *q
+	err = run(ctx, session, func(s *mgo.Session) (err error) {
+		count, err = query.prepare(s).Limit(1).Count()
+		return
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Distinct fills out, which must be pointer to a slice, with distinct values
+// of the field among documents matching the query filter.
+func (q *Query) Distinct(reuseSocket bool, field string, out interface{}) error {
+	return q.DistinctCtx(context.Background(), reuseSocket, field, out)
+}
+
+// DistinctCtx is Distinct bound by the context
+func (q *Query) DistinctCtx(ctx context.Context, reuseSocket bool, field string, out interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	key, err := q.model.bsonKey(field)
+	if err != nil {
+		return err
+	}
+
+	session, err := q.readSession(reuseSocket)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	command := bson.D{{Name: "distinct", Value: q.collection}, {Name: "key", Value: key}}
+	if selector := q.selector(); selector != nil {
+		command = append(command, bson.DocElem{Name: "query", Value: selector})
+	}
+	remaining, err := remainingTime(ctx)
+	if err != nil {
+		return err
+	} else if remaining > 0 {
+		command = append(command, bson.DocElem{Name: "maxTimeMS", Value: int64(remaining / time.Millisecond)})
+	}
+
+	// Values are decoded only once the command finishes, so that out is not filled after cancellation
+	var result struct {
+		Values bson.Raw
+	}
+	database := q.Database
+	err = run(ctx, session, func(s *mgo.Session) error {
+		return s.DB(database).Run(command, &result)
+	})
+	if err != nil {
+		return err
+	}
+	return result.Values.Unmarshal(out)
+}
+
+// prepare builds mgo query with all restrictions applied
+func (q *Query) prepare(session *mgo.Session) *mgo.Query {
+	query := session.DB(q.Database).C(q.collection).Find(q.selector())
+
+	if len(q.sort) > 0 {
+		query = query.Sort(q.sort...)
+	}
+
+	if q.projection != nil {
+		query = query.Select(q.projection)
+	}
+
+	if q.skip > 0 {
+		query = query.Skip(q.skip)
+	}
+
+	if q.limit > 0 {
+		query = query.Limit(q.limit)
+	}
+	return query
+}
+
+// Err returns first error encountered while building the query
+func (q *Query) Err() error {
+	return q.err
 }
 
 func (q *Query) Collection() string {
 	return q.collection
 }
 
+// Filter restricts result to documents matching the filter. Filter can be
+// a map, a struct or a Condition built by filter functions (Eq, In, Or, ...).
 func (q *Query) Filter(filter interface{}) *Query {
+	if condition, ok := filter.(Condition); ok {
+		built, err := condition.build(q.model.bsonKey)
+		if err != nil {
+			q.setErr(err)
+			return q
+		}
+		filter = built
+	}
 	q.filter = filter
 	return q
 }
 
+// Where narrows current filter of the query by additional conditions
+func (q *Query) Where(conditions ...Condition) *Query {
+	built, err := buildAll(conditions, q.model.bsonKey)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	if q.filter != nil {
+		built = append([]interface{}{q.filter}, built...)
+	}
+	if len(built) == 1 {
+		q.filter = built[0]
+	} else if len(built) > 1 {
+		q.filter = bson.M{"$and": built}
+	}
+	return q
+}
+
 func (q *Query) GetFilter() interface{} {
 	return q.filter
 }
@@ -115,18 +309,106 @@ func (q *Query) GetSkip() int {
 	return q.skip
 }
 
-func (q *Query) initResult() error {
-	var err error
-	resultv := reflect.ValueOf(q.result)
+// Sort orders result by given fields. Fields can be referenced by struct field
+// names or bson names, prefix '-' denotes descending order.
+func (q *Query) Sort(fields ...string) *Query {
+	q.sort = nil
+	for _, field := range fields {
+		prefix := ""
+		if strings.HasPrefix(field, "-") {
+			prefix, field = "-", field[1:]
+		}
+		key, err := q.model.bsonKey(field)
+		if err != nil {
+			q.setErr(err)
+			continue
+		}
+		q.sort = append(q.sort, prefix+key)
+	}
+	return q
+}
+
+func (q *Query) GetSort() []string {
+	return q.sort
+}
 
-	if q.singleValue {
-		initPrototype(resultv, q.resultType)
-	} else {
-		slicev := resultv.Elem()
-		for i := 0; i < slicev.Len(); i++ {
-			initPrototype(slicev.Index(i).Addr(), q.resultType)
+// Select loads only given fields of the documents. Documents loaded this way
+// are marked as partial and Save never writes fields that were not loaded.
+func (q *Query) Select(fields ...string) *Query {
+	keys := q.projectionKeys(fields)
+	q.projection = bson.M{}
+	q.omitted = make(map[string]bool)
+
+	// Fields maintained by jc must be loaded, otherwise the document could not be saved
+	for _, key := range append(keys, q.model.maintainedKeys()...) {
+		q.projection[key] = 1
+	}
+	for _, key := range q.model._keys {
+		if _, selected := q.projection[key]; !selected && key != "_id" {
+			q.omitted[key] = true
+		}
+	}
+	return q
+}
+
+// Omit loads all but given fields of the documents. Documents loaded this way
+// are marked as partial and Save never writes fields that were not loaded.
+func (q *Query) Omit(fields ...string) *Query {
+	keys := q.projectionKeys(fields)
+	q.projection = bson.M{}
+	q.omitted = make(map[string]bool)
+
+	for _, key := range keys {
+		if key == "_id" {
+			q.setErr(errors.New("can't omit document ID"))
+			continue
+		}
+		if q.model.isMaintained(key) {
+			q.setErr(errors.New(fmt.Sprintf("can't omit field '%s' maintained by jc", key)))
+			continue
 		}
+		q.projection[key] = 0
+		q.omitted[key] = true
 	}
+	return q
+}
+
+func (q *Query) GetProjection() bson.M {
+	return q.projection
+}
+
+// projectionKeys translates field names used in projection. Only top level
+// fields can be projected, so that partially loaded documents can be saved safely.
+func (q *Query) projectionKeys(fields []string) (keys []string) {
+	for _, field := range fields {
+		key, err := q.model.bsonKey(field)
+		if err == nil && strings.Contains(key, ".") {
+			err = errors.New(fmt.Sprintf("can't project nested field '%s'", field))
+		}
+		if err != nil {
+			q.setErr(err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
 
-	return err
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// loadSlice replaces content of the slice that result points to with documents loaded from raws
+func loadSlice(raws []bson.Raw, result interface{}, resultType reflect.Type, omitted map[string]bool) error {
+	slicev := reflect.MakeSlice(reflect.TypeOf(result).Elem(), len(raws), len(raws))
+	for i, raw := range raws {
+		err := loadDocument(raw, slicev.Index(i).Addr(), resultType, omitted)
+		if err != nil {
+			return err
+		}
+	}
+	reflect.ValueOf(result).Elem().Set(slicev)
+	return nil
 }
@@ -0,0 +1,59 @@
+package jc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"gopkg.in/mgo.v2"
+)
+
+// readModes maps read preference names, as used in MongoDB connection strings,
+// to consistency modes of mgo.
+var readModes = map[string]mgo.Mode{
+	"primary":            mgo.Primary,
+	"primarypreferred":   mgo.PrimaryPreferred,
+	"secondary":          mgo.Secondary,
+	"secondarypreferred": mgo.SecondaryPreferred,
+	"nearest":            mgo.Nearest,
+	"eventual":           mgo.Eventual,
+	"monotonic":          mgo.Monotonic,
+	"strong":             mgo.Strong,
+}
+
+func parseReadMode(name string) (mgo.Mode, error) {
+	mode, found := readModes[strings.ToLower(name)]
+	if !found {
+		return mode, errors.New(fmt.Sprintf("unknown read preference '%s'", name))
+	}
+	return mode, nil
+}
+
+// ReadPreference makes query read from members of replica set selected by
+// mode, e.g. 'secondaryPreferred'. Modes 'primary', 'primaryPreferred',
+// 'secondary', 'secondaryPreferred' and 'nearest' as well as mgo consistency
+// modes 'eventual', 'monotonic' and 'strong' are recognized.
+func (q *Query) ReadPreference(mode string) *Query {
+	consistency, err := parseReadMode(mode)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	return q.Consistency(consistency)
+}
+
+// Consistency sets consistency mode of the session used by the query.
+// Unless it's set, query uses read preference declared by the model or
+// mode of the master session.
+func (q *Query) Consistency(mode mgo.Mode) *Query {
+	q.readMode = &mode
+	return q
+}
+
+// readSession returns session for read operations of the query
+func (q *Query) readSession(reuseSocket bool) (*mgo.Session, error) {
+	session, err := getSession(q.model._connection, reuseSocket)
+	if err == nil && q.readMode != nil {
+		session.SetMode(*q.readMode, true)
+	}
+	return session, err
+}
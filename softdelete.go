@@ -0,0 +1,65 @@
+package jc
+
+import (
+	"reflect"
+	"time"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Soft deleted documents are marked by this field instead of being removed from DB
+const deletedAtKey = "deleted_at"
+
+// removeByID removes document with given ID from collection, or only marks it
+// as deleted if soft delete is requested.
+func removeByID(collection *mgo.Collection, id interface{}, soft bool, now time.Time) error {
+	var err error
+	if soft {
+		err = collection.Update(
+			bson.M{"_id": id, deletedAtKey: nil},
+			bson.M{"$set": bson.M{deletedAtKey: now}})
+	} else {
+		err = collection.RemoveId(id)
+	}
+
+	if err == mgo.ErrNotFound {
+		err = ErrNotFound
+	}
+	return err
+}
+
+// markDeleted reflects soft delete in document's field mapped to 'deleted_at', if there is one
+func (c *Collection) markDeleted(now time.Time) {
+	name, found := c.fieldByKey(deletedAtKey)
+	if !found {
+		return
+	}
+	field := c._parent.Elem().FieldByName(name)
+	if field.Type() != reflect.TypeOf(now) {
+		return
+	}
+
+	field.Set(reflect.ValueOf(now))
+	if c._snapshot != nil {
+		c._snapshot[deletedAtKey] = now
+	}
+}
+
+// WithDeleted makes query include soft deleted documents
+func (q *Query) WithDeleted() *Query {
+	q.withDeleted = true
+	return q
+}
+
+// selector returns query filter extended by conditions implied by the model
+func (q *Query) selector() interface{} {
+	if !q.model._softDelete || q.withDeleted {
+		return q.filter
+	}
+
+	notDeleted := bson.M{deletedAtKey: nil}
+	if q.filter == nil {
+		return notDeleted
+	}
+	return bson.M{"$and": []interface{}{q.filter, notDeleted}}
+}
@@ -0,0 +1,111 @@
+package jc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Options recognized in 'jc' tags of embedded Collection and of model fields
+var (
+	knownCollectionOptions = []string{"conn", "db", "read", "softdelete", "index", "unique"}
+	knownFieldOptions      = []string{"required", "min", "max", "regex", "created_at", "updated_at", "version", "index", "unique", "ttl"}
+)
+
+// parseTagOptions splits 'jc' tag of the model field into options and their
+// values. Option 'regex' consumes the rest of the tag, so that the pattern
+// can contain commas.
+func parseTagOptions(tag string) map[string]string {
+	options := make(map[string]string)
+	for tag != "" {
+		var option string
+		if strings.HasPrefix(tag, "regex=") {
+			option, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			option, tag = tag[:i], tag[i+1:]
+		} else {
+			option, tag = tag, ""
+		}
+
+		name, value := option, ""
+		if i := strings.Index(option, "="); i >= 0 {
+			name, value = option[:i], option[i+1:]
+		}
+		if name != "" {
+			options[strings.TrimSpace(name)] = value
+		}
+	}
+	return options
+}
+
+// parseCollectionOptions processes options from 'jc' tag of embedded Collection
+// that follow the collection name.
+func (c *Collection) parseCollectionOptions(options map[string]string) {
+	c.tagError(unknownOption(options, knownCollectionOptions, "Collection"))
+	c._connection = options["conn"]
+	c._explicitDB = options["db"]
+	if name, found := options["read"]; found {
+		mode, err := parseReadMode(name)
+		c.tagError(err)
+		if err == nil {
+			c._readMode = &mode
+		}
+	}
+	if _, found := options["softdelete"]; found {
+		c._softDelete = true
+	}
+	if declaration, found := options["index"]; found {
+		c.tagError(c.parseCompoundIndexes(declaration, false))
+	}
+	if declaration, found := options["unique"]; found {
+		c.tagError(c.parseCompoundIndexes(declaration, true))
+	}
+}
+
+// parseFieldTag processes options from 'jc' tag of the model field
+func (c *Collection) parseFieldTag(field reflect.StructField, key string) {
+	tag, tagged := field.Tag.Lookup("jc")
+	if !tagged {
+		return
+	}
+	options := parseTagOptions(tag)
+	c.tagError(unknownOption(options, knownFieldOptions, fmt.Sprintf("field '%s'", field.Name)))
+
+	rule, err := newFieldRule(field, key, options)
+	c.tagError(err)
+	if rule != nil {
+		c._rules = append(c._rules, *rule)
+	}
+
+	c.tagError(c.parseTimestampTag(field, key, options))
+	c.tagError(c.parseVersionTag(field, key, options))
+	c.tagError(c.parseIndexTag(field, key, options))
+}
+
+// tagError remembers first error found in model tags
+func (c *Collection) tagError(err error) {
+	if err != nil && c._tagError == nil {
+		c._tagError = err
+	}
+}
+
+// unknownOption returns error describing the first option not listed in known
+func unknownOption(options map[string]string, known []string, owner string) error {
+	var unknown []string
+	for name := range options {
+		recognized := false
+		for _, option := range known {
+			recognized = recognized || name == option
+		}
+		if !recognized {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return errors.New(fmt.Sprintf("unknown option '%s' on %s", unknown[0], owner))
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAggregateGroup(t *testing.T) {
+	preparePeople()
+
+	var result []struct {
+		LastName string `bson:"_id"`
+		Total    int    `bson:"total"`
+	}
+	a, err := jc.NewAggregate(&Person{}, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Group(bson.M{"_id": "$last_name", "total": bson.M{"$sum": "$age"}}).Sort("_id").Execute(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{"Bar": 40, "Baz": 0, "Foo": 50}
+	if len(result) != len(expected) {
+		t.Fatal(fmt.Sprintf("Unexpected number of groups. Expected %d, got %d", len(expected), len(result)))
+	}
+	for _, group := range result {
+		if expected[group.LastName] != group.Total {
+			t.Error(fmt.Sprintf("Unexpected total for '%s'. Expected %d, got %d",
+				group.LastName, expected[group.LastName], group.Total))
+		}
+	}
+}
+
+func TestAggregateIntoModels(t *testing.T) {
+	preparePeople()
+
+	var result []Person
+	a, _ := jc.NewAggregate(&Person{}, &result)
+	a.Match(jc.Eq("LastName", "Foo")).Sort("-Age").Limit(1).Execute(true)
+
+	if len(result) != 1 || result[0].MyID != 2 {
+		t.Fatal("Failed to aggregate documents into models")
+	}
+	if !result[0].IsInitialized() || result[0].CollectionName() != "person" {
+		t.Error("Aggregated model was not initialized")
+	}
+}
+
+func TestAggregateFacet(t *testing.T) {
+	preparePeople()
+
+	var result struct {
+		Adults []Person `bson:"adults"`
+		Count  []bson.M `bson:"count"`
+	}
+	adults, _ := jc.NewAggregate(&Person{}, nil)
+	count, _ := jc.NewAggregate(&Person{}, nil)
+	a, _ := jc.NewAggregate(&Person{}, &result)
+
+	err := a.Facet(map[string]*jc.Aggregate{
+		"adults": adults.Match(jc.Gte("Age", 18)),
+		"count":  count.Stage(bson.M{"$count": "n"}),
+	}).Execute(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Adults) != 3 || len(result.Count) != 1 {
+		t.Error(fmt.Sprintf("Unexpected facet result %+v", result))
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestQueryUpdateAll(t *testing.T) {
+	preparePeople()
+
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+	info, err := q.Filter(jc.Eq("LastName", "Foo")).UpdateAll(true, bson.M{"$inc": bson.M{"age": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Updated != 2 {
+		t.Error(fmt.Sprintf("Unexpected number of updated documents. Expected %d, got %d", 2, info.Updated))
+	}
+
+	q.Filter(jc.Eq("LastName", "Foo")).Sort("MyID").Execute(true)
+	if docs[0].Age != 21 || docs[1].Age != 31 {
+		t.Error("UpdateAll did not update documents")
+	}
+}
+
+func TestQueryUpdateOne(t *testing.T) {
+	preparePeople()
+
+	var doc Person
+	q, _ := jc.NewQuery(&doc)
+	_, err := q.Filter(jc.Eq("MyID", 3)).UpdateOne(true, bson.M{"$set": bson.M{"first_name": "Joe"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.Execute(true)
+	if doc.FirstName != "Joe" {
+		t.Error("UpdateOne did not update document")
+	}
+
+	info, err := q.UpdateOne(true, bson.M{"$set": bson.M{"first_name": "Joe"}})
+	if err != nil || info.Matched != 1 || info.Updated != 0 {
+		t.Error(fmt.Sprintf("Repeated UpdateOne should match document without modifying it. Got %+v (%v)", info, err))
+	}
+
+	_, err = q.Filter(jc.Eq("MyID", 100)).UpdateOne(true, bson.M{"$set": bson.M{"first_name": "Joe"}})
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound when updating missing document, got '%v'", err))
+	}
+}
+
+func TestQueryDeleteAll(t *testing.T) {
+	preparePeople()
+
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+	info, err := q.Filter(jc.Gte("Age", 30)).DeleteAll(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Removed != 2 {
+		t.Error(fmt.Sprintf("Unexpected number of removed documents. Expected %d, got %d", 2, info.Removed))
+	}
+
+	count, _ := q.Filter(nil).Count(true)
+	if count != 2 {
+		t.Error(fmt.Sprintf("DeleteAll did not remove documents. Expected %d left, got %d", 2, count))
+	}
+}
+
+func TestQueryDeleteOneSoft(t *testing.T) {
+	dropTestDB()
+	doc := SoftDeleted{MyID: 1, Data: "TestQueryDeleteOneSoft"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	var docs []SoftDeleted
+	q, _ := jc.NewQuery(&docs)
+	_, err := q.Filter(jc.Eq("MyID", 1)).DeleteOne(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, _ := q.Exists(true)
+	deleted, _ := q.WithDeleted().Exists(true)
+	if exists || !deleted {
+		t.Error("DeleteOne did not soft delete document")
+	}
+}
@@ -3,35 +3,32 @@ package tests
 import (
 	"testing"
 	"github.com/kalcok/jc/tools"
-	"os"
 	"github.com/kalcok/jc"
 	"gopkg.in/mgo.v2/bson"
 	"reflect"
 	"gopkg.in/mgo.v2"
+	"fmt"
+	"time"
 )
 
 var (
-	sessionDB  string
-	mgoSession *mgo.Session
+	sessionDB   string
+	sessionConf tools.SessionConf
+	mgoSession  *mgo.Session
 )
 
 func initTestSession() {
-	address, found := os.LookupEnv("JC_TEST_ADDRESS")
-	if !found {
-		address = "localhost"
+	conf, err := tools.ConfFromEnvPrefix("JC_TEST_")
+	if err != nil {
+		panic(err)
 	}
-
-	db, found := os.LookupEnv("JC_TEST_DB")
-	if !found {
-		db = "jc_test"
+	if conf.Database == "" {
+		conf.Database = "jc_test"
 	}
-	sessionDB = db
-
-	pass, _ := os.LookupEnv("JC_TEST_PASS")
-	user, _ := os.LookupEnv("JC_TEST_USER")
+	sessionDB = conf.Database
 
-	conf := tools.SessionConf{Database: db, Addrs: []string{address}, Password: pass, Username: user}
-	tools.InitSession(&conf)
+	sessionConf = *conf
+	tools.InitSession(&sessionConf)
 }
 
 func dropTestDB() {
@@ -320,4 +317,431 @@ func TestIsInitializedFlag(t *testing.T) {
 	if !doc.IsInitialized() {
 		t.Error("Initialization did not set _initialized flag")
 	}
-}
\ No newline at end of file
+}
+func TestDeleteExplicitID(t *testing.T) {
+	id := 4040
+	doc := ExplicitID{Data: "TestDeleteExplicitID", MyID: id}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	err := doc.Delete(true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	count, _ := session.DB(sessionDB).C(doc.CollectionName()).FindId(id).Count()
+	if count != 0 {
+		t.Error("Failed to delete document with explicit ID")
+	}
+}
+
+func TestDeleteImplicitID(t *testing.T) {
+	doc := ImplicitID{Data: "TestDeleteImplicitID"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	err := doc.Delete(true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	count, _ := session.DB(sessionDB).C(doc.CollectionName()).FindId(doc.ID()).Count()
+	if count != 0 {
+		t.Error("Failed to delete document with implicit ID")
+	}
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	doc := ExplicitID{Data: "TestDeleteNotFound", MyID: 4041}
+	jc.NewDocument(&doc)
+
+	err := doc.Delete(true)
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound when deleting missing document, got '%v'", err))
+	}
+
+	unsaved := ImplicitID{Data: "TestDeleteNotFound"}
+	jc.NewDocument(&unsaved)
+	err = unsaved.Delete(true)
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound when deleting unsaved document, got '%v'", err))
+	}
+}
+
+func TestDeleteByID(t *testing.T) {
+	id := 4042
+	doc := ExplicitID{Data: "TestDeleteByID", MyID: id}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	err := jc.DeleteByID(&ExplicitID{}, id)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = jc.DeleteByID(&ExplicitID{}, id)
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound on repeated DeleteByID, got '%v'", err))
+	}
+}
+
+func TestReload(t *testing.T) {
+	original := "TestReload"
+	updated := "TestReloadUpdated"
+	doc := ImplicitID{Data: original}
+	jc.NewDocument(&doc)
+	doc.SetDatabase(sessionDB)
+	doc.Save(true)
+	id := doc.ID()
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	session.DB(sessionDB).C(doc.CollectionName()).UpdateId(id, bson.M{"$set": bson.M{"data": updated}})
+
+	err := doc.Reload(true)
+	if err != nil {
+		t.Error(err)
+	}
+	if doc.Data != updated {
+		t.Error(fmt.Sprintf("Failed to reload document. Expected '%s', got '%s'", updated, doc.Data))
+	}
+	if doc.ID() != id || doc.Database() != sessionDB || !doc.IsInitialized() {
+		t.Error("Reload did not preserve document metadata")
+	}
+}
+
+func TestReloadNotFound(t *testing.T) {
+	doc := ExplicitID{Data: "TestReloadNotFound", MyID: 5050}
+	jc.NewDocument(&doc)
+
+	err := doc.Reload(true)
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound when reloading missing document, got '%v'", err))
+	}
+	if doc.Data != "TestReloadNotFound" {
+		t.Error("Failed reload modified document")
+	}
+}
+
+func TestInsert(t *testing.T) {
+	doc := ImplicitID{Data: "TestInsert"}
+	jc.NewDocument(&doc)
+
+	err := doc.Insert(true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	result := bson.M{}
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	session.DB(sessionDB).C(doc.CollectionName()).FindId(doc.ID()).One(&result)
+	if result["data"] != "TestInsert" {
+		t.Error("Failed to insert document with implicit ID into DB")
+	}
+}
+
+func TestInsertDuplicate(t *testing.T) {
+	original := "TestInsertDuplicate"
+	doc := ExplicitID{Data: original, MyID: 6060}
+	jc.NewDocument(&doc)
+
+	err := doc.Insert(true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	duplicate := ExplicitID{Data: "TestInsertDuplicateOverwrite", MyID: 6060}
+	jc.NewDocument(&duplicate)
+	err = duplicate.Insert(true)
+	if err != jc.ErrDuplicateKey {
+		t.Error(fmt.Sprintf("Expected ErrDuplicateKey when inserting duplicate document, got '%v'", err))
+	}
+
+	result := bson.M{}
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	session.DB(sessionDB).C(doc.CollectionName()).FindId(6060).One(&result)
+	if result["data"] != original {
+		t.Error("Insert overwrote existing document")
+	}
+}
+
+func TestNewDocumentIsDirty(t *testing.T) {
+	doc := ImplicitID{Data: "TestNewDocumentIsDirty"}
+	jc.NewDocument(&doc)
+
+	if !doc.IsDirty() {
+		t.Error("Document that was never saved is not reported as dirty")
+	}
+
+	doc.Save(true)
+	if doc.IsDirty() {
+		t.Error("Document is reported as dirty right after Save")
+	}
+	if len(doc.ChangedFields()) != 0 {
+		t.Error(fmt.Sprintf("Unexpected changed fields after Save: %v", doc.ChangedFields()))
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	doc := ExplicitID{Data: "TestChangedFields", MyID: 7070}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	doc.Data = "TestChangedFieldsUpdated"
+	changed := doc.ChangedFields()
+	if !doc.IsDirty() || len(changed) != 1 || changed[0] != "Data" {
+		t.Error(fmt.Sprintf("Expected changed fields [Data], got %v", changed))
+	}
+}
+
+func TestPartialSavePreservesConcurrentChanges(t *testing.T) {
+	type twoFields struct {
+		jc.Collection `bson:"-"json:"-"`
+		MyID          int    `bson:"_id"`
+		First         string `bson:"first"`
+		Second        string `bson:"second"`
+	}
+
+	doc := twoFields{MyID: 1, First: "first", Second: "second"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	collection := session.DB(sessionDB).C(doc.CollectionName())
+	collection.UpdateId(1, bson.M{"$set": bson.M{"second": "concurrent"}})
+
+	doc.First = "updated"
+	doc.Save(true)
+
+	result := bson.M{}
+	collection.FindId(1).One(&result)
+	if result["first"] != "updated" || result["second"] != "concurrent" {
+		t.Error(fmt.Sprintf("Save did not update only changed fields. Got %v", result))
+	}
+}
+
+func TestPartialSaveOfDeletedDocument(t *testing.T) {
+	dropTestDB()
+	doc := ExplicitID{MyID: 1, Data: "TestPartialSaveOfDeletedDocument"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	collection := session.DB(sessionDB).C(doc.CollectionName())
+	collection.RemoveId(1)
+
+	doc.Data = "updated"
+	_, err := doc.Save(true)
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound when saving changes of deleted document, got '%v'", err))
+	}
+
+	count, _ := collection.FindId(1).Count()
+	if count != 0 {
+		t.Error("Save of changed fields recreated deleted document")
+	}
+}
+
+func TestSaveHooks(t *testing.T) {
+	doc := Hooked{MyID: 8080, Data: "  TestSaveHooks  "}
+	jc.NewDocument(&doc)
+
+	_, err := doc.Save(true)
+	if err != nil {
+		t.Error(err)
+	}
+	if doc.Data != "TestSaveHooks" {
+		t.Error("BeforeSave hook was not called")
+	}
+	if !doc.Saved {
+		t.Error("AfterSave hook was not called")
+	}
+}
+
+func TestBeforeSaveAbort(t *testing.T) {
+	doc := Hooked{MyID: 8081, Data: "   "}
+	jc.NewDocument(&doc)
+
+	_, err := doc.Save(true)
+	if err == nil {
+		t.Error("Error from BeforeSave hook did not abort Save")
+	}
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	count, _ := session.DB(sessionDB).C(doc.CollectionName()).FindId(8081).Count()
+	if count != 0 || doc.Saved {
+		t.Error("Document was saved despite BeforeSave hook error")
+	}
+}
+
+func TestBeforeDeleteAbort(t *testing.T) {
+	doc := Hooked{MyID: 8082, Data: "protected"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	if doc.Delete(true) == nil {
+		t.Error("Error from BeforeDelete hook did not abort Delete")
+	}
+	if jc.DeleteByID(&Hooked{}, 8082) == nil {
+		t.Error("Error from BeforeDelete hook did not abort DeleteByID")
+	}
+}
+
+func TestTimestampsOnInsert(t *testing.T) {
+	doc := Stamped{MyID: 9090, Data: "TestTimestampsOnInsert"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	if doc.Created.IsZero() || doc.Updated.IsZero() {
+		t.Error("Save did not set timestamps on newly inserted document")
+	}
+
+	result := Stamped{}
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	session.DB(sessionDB).C(doc.CollectionName()).FindId(9090).One(&result)
+	if !result.Created.Equal(doc.Created) || !result.Updated.Equal(doc.Updated) {
+		t.Error("Timestamps in DB don't match timestamps of the document")
+	}
+}
+
+func TestTimestampsOnUpdate(t *testing.T) {
+	doc := Stamped{MyID: 9091, Data: "TestTimestampsOnUpdate"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+	created := doc.Created
+	updated := doc.Updated
+
+	time.Sleep(5 * time.Millisecond)
+	doc.Data = "TestTimestampsOnUpdateUpdated"
+	doc.Save(true)
+	if !doc.Created.Equal(created) {
+		t.Error("Save changed creation timestamp of existing document")
+	}
+	if !doc.Updated.After(updated) {
+		t.Error("Save did not change update timestamp")
+	}
+
+	// Overwriting existing document must not change its creation timestamp
+	overwrite := Stamped{MyID: 9091, Data: "TestTimestampsOnUpdateOverwrite"}
+	jc.NewDocument(&overwrite)
+	overwrite.Save(true)
+
+	result := Stamped{}
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	session.DB(sessionDB).C(doc.CollectionName()).FindId(9091).One(&result)
+	if !result.Created.Equal(created) {
+		t.Error("Overwriting document changed its creation timestamp")
+	}
+	if !overwrite.Created.Equal(created) {
+		t.Error("Overwriting document did not load its creation timestamp")
+	}
+}
+
+func TestTimestampsOverwriteReplacesDocument(t *testing.T) {
+	doc := Stamped{MyID: 9092, Data: "TestTimestampsOverwriteReplacesDocument"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	collection := session.DB(sessionDB).C(doc.CollectionName())
+	collection.UpdateId(9092, bson.M{"$set": bson.M{"extra": "value"}})
+
+	overwrite := Stamped{MyID: 9092, Data: "TestTimestampsOverwriteReplacesDocumentOverwrite"}
+	jc.NewDocument(&overwrite)
+	_, err := overwrite.Save(true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	result := bson.M{}
+	collection.FindId(9092).One(&result)
+	if _, ok := result["extra"]; ok {
+		t.Error("Overwriting document with creation timestamp did not replace it")
+	}
+	if result["data"] != overwrite.Data {
+		t.Error(fmt.Sprintf("Overwriting document stored '%v' instead of '%s'", result["data"], overwrite.Data))
+	}
+}
+
+func TestVersionIncrement(t *testing.T) {
+	doc := Versioned{MyID: 10100, Data: "TestVersionIncrement"}
+	jc.NewDocument(&doc)
+
+	doc.Save(true)
+	if doc.Version != 1 {
+		t.Error(fmt.Sprintf("Save did not increment version. Expected 1, got %d", doc.Version))
+	}
+
+	doc.Data = "TestVersionIncrementUpdated"
+	doc.Save(true)
+	if doc.Version != 2 {
+		t.Error(fmt.Sprintf("Save did not increment version. Expected 2, got %d", doc.Version))
+	}
+}
+
+func TestStaleDocument(t *testing.T) {
+	doc := Versioned{MyID: 10101, Data: "TestStaleDocument"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	first := Versioned{MyID: 10101}
+	jc.NewDocument(&first)
+	first.Reload(true)
+	second := Versioned{MyID: 10101}
+	jc.NewDocument(&second)
+	second.Reload(true)
+
+	first.Data = "First writer"
+	_, err := first.Save(true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	second.Data = "Second writer"
+	_, err = second.Save(true)
+	if err != jc.ErrStaleDocument {
+		t.Error(fmt.Sprintf("Expected ErrStaleDocument when saving outdated document, got '%v'", err))
+	}
+	if second.Version != 1 {
+		t.Error("Failed Save changed version of the document")
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	doc := SoftDeleted{MyID: 11110, Data: "TestSoftDelete"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	err := doc.Delete(true)
+	if err != nil {
+		t.Error(err)
+	}
+	if doc.Deleted.IsZero() {
+		t.Error("Soft delete did not set deletion timestamp of the document")
+	}
+
+	result := bson.M{}
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	session.DB(sessionDB).C(doc.CollectionName()).FindId(11110).One(&result)
+	if _, found := result["deleted_at"]; !found {
+		t.Error("Soft delete did not mark document as deleted in DB")
+	}
+
+	err = jc.DeleteByID(&SoftDeleted{}, 11110)
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound when deleting soft deleted document, got '%v'", err))
+	}
+}
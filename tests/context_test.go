@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"context"
+	"time"
+	"fmt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestContextOperations(t *testing.T) {
+	dropTestDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := ExplicitID{MyID: 1, Data: "TestContextOperations"}
+	jc.NewDocument(&doc)
+	_, err := doc.SaveCtx(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded []ExplicitID
+	q, _ := jc.NewQuery(&loaded)
+	err = q.ExecuteCtx(ctx, true)
+	if err != nil || len(loaded) != 1 || loaded[0].Data != doc.Data {
+		t.Error(fmt.Sprintf("ExecuteCtx did not load saved document. Got %v (%v)", loaded, err))
+	}
+
+	count, err := q.CountCtx(ctx, true)
+	if err != nil || count != 1 {
+		t.Error(fmt.Sprintf("CountCtx returned %d (%v)", count, err))
+	}
+
+	err = doc.DeleteCtx(ctx, true)
+	if err != nil {
+		t.Error(fmt.Sprintf("DeleteCtx failed. %s", err))
+	}
+	exists, _ := q.ExistsCtx(ctx, true)
+	if exists {
+		t.Error("Document still exists after DeleteCtx")
+	}
+}
+
+func TestContextCancelled(t *testing.T) {
+	dropTestDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	doc := ExplicitID{MyID: 1, Data: "TestContextCancelled"}
+	jc.NewDocument(&doc)
+	_, err := doc.SaveCtx(ctx, true)
+	if err != context.Canceled {
+		t.Error(fmt.Sprintf("SaveCtx with cancelled context returned '%v'", err))
+	}
+	err = doc.InsertCtx(ctx, true)
+	if err != context.Canceled {
+		t.Error(fmt.Sprintf("InsertCtx with cancelled context returned '%v'", err))
+	}
+
+	var loaded []ExplicitID
+	q, _ := jc.NewQuery(&loaded)
+	count, _ := q.Count(true)
+	if count != 0 {
+		t.Error("Document was saved despite cancelled context")
+	}
+}
+
+func TestContextDeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	var loaded []ExplicitID
+	q, _ := jc.NewQuery(&loaded)
+	err := q.ExecuteCtx(ctx, true)
+	if err != context.DeadlineExceeded {
+		t.Error(fmt.Sprintf("ExecuteCtx after deadline returned '%v'", err))
+	}
+
+	doc := ExplicitID{MyID: 1}
+	jc.NewDocument(&doc)
+	err = doc.ReloadCtx(ctx, true)
+	if err != context.DeadlineExceeded {
+		t.Error(fmt.Sprintf("ReloadCtx after deadline returned '%v'", err))
+	}
+}
+
+func TestContextQueryOperations(t *testing.T) {
+	preparePeople()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+	info, err := q.Filter(jc.Eq("MyID", 1)).UpdateOneCtx(ctx, true, bson.M{"$set": bson.M{"first_name": "Joe"}})
+	if err != nil || info.Updated != 1 {
+		t.Error(fmt.Sprintf("UpdateOneCtx did not update document. Got %+v (%v)", info, err))
+	}
+
+	var lastNames []string
+	err = q.Filter(nil).DistinctCtx(ctx, true, "LastName", &lastNames)
+	if err != nil || len(lastNames) == 0 {
+		t.Error(fmt.Sprintf("DistinctCtx returned %v (%v)", lastNames, err))
+	}
+
+	seen := 0
+	err = q.ForEachCtx(ctx, true, func(doc interface{}) error {
+		seen++
+		return nil
+	})
+	if err != nil || seen == 0 {
+		t.Error(fmt.Sprintf("ForEachCtx visited %d documents (%v)", seen, err))
+	}
+
+	var groups []bson.M
+	a, _ := jc.NewAggregate(&Person{}, &groups)
+	err = a.Group(bson.M{"_id": "$last_name"}).ExecuteCtx(ctx, true)
+	if err != nil || len(groups) != len(lastNames) {
+		t.Error(fmt.Sprintf("Aggregate ExecuteCtx returned %v (%v)", groups, err))
+	}
+}
+
+func TestContextCancelledQueryOperations(t *testing.T) {
+	preparePeople()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var doc Person
+	q, _ := jc.NewQuery(&doc)
+	q.Filter(jc.Eq("MyID", 1))
+
+	if _, err := q.UpdateAllCtx(ctx, true, bson.M{"$set": bson.M{"first_name": "Joe"}}); err != context.Canceled {
+		t.Error(fmt.Sprintf("UpdateAllCtx with cancelled context returned '%v'", err))
+	}
+	if _, err := q.DeleteOneCtx(ctx, true); err != context.Canceled {
+		t.Error(fmt.Sprintf("DeleteOneCtx with cancelled context returned '%v'", err))
+	}
+	if _, err := q.FindAndModifyCtx(ctx, true, jc.Change{Remove: true}); err != context.Canceled {
+		t.Error(fmt.Sprintf("FindAndModifyCtx with cancelled context returned '%v'", err))
+	}
+	var lastNames []string
+	if err := q.DistinctCtx(ctx, true, "LastName", &lastNames); err != context.Canceled {
+		t.Error(fmt.Sprintf("DistinctCtx with cancelled context returned '%v'", err))
+	}
+	if _, err := q.IterCtx(ctx, true); err != context.Canceled {
+		t.Error(fmt.Sprintf("IterCtx with cancelled context returned '%v'", err))
+	}
+	if err := jc.DeleteByIDCtx(ctx, &Person{}, 1); err != context.Canceled {
+		t.Error(fmt.Sprintf("DeleteByIDCtx with cancelled context returned '%v'", err))
+	}
+	if _, err := jc.EnsureIndexesCtx(ctx, &Indexed{}); err != context.Canceled {
+		t.Error(fmt.Sprintf("EnsureIndexesCtx with cancelled context returned '%v'", err))
+	}
+	if err := doc.InitDBCtx(ctx); err != context.Canceled {
+		t.Error(fmt.Sprintf("InitDBCtx with cancelled context returned '%v'", err))
+	}
+
+	var groups []bson.M
+	a, _ := jc.NewAggregate(&Person{}, &groups)
+	if err := a.ExecuteCtx(ctx, true); err != context.Canceled {
+		t.Error(fmt.Sprintf("Aggregate ExecuteCtx with cancelled context returned '%v'", err))
+	}
+
+	err := q.Execute(true)
+	if err != nil || doc.FirstName != "John" {
+		t.Error("Operation with cancelled context modified document")
+	}
+}
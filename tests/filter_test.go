@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+)
+
+func preparePeople() {
+	dropTestDB()
+	people := []Person{
+		{MyID: 1, FirstName: "John", LastName: "Foo", Age: 20},
+		{MyID: 2, FirstName: "Jane", LastName: "Foo", Age: 30},
+		{MyID: 3, FirstName: "Jack", LastName: "Bar", Age: 40},
+		{MyID: 4, FirstName: "Jill", LastName: "Baz", Age: 0},
+	}
+	for i := range people {
+		jc.NewDocument(&people[i])
+		people[i].Save(true)
+	}
+}
+
+func filteredIDs(t *testing.T, conditions ...jc.Condition) (ids []int) {
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+	err := q.Where(conditions...).Sort("MyID").Execute(true)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, doc := range docs {
+		ids = append(ids, doc.MyID)
+	}
+	return ids
+}
+
+func expectIDs(t *testing.T, name string, expected []int, got []int) {
+	if fmt.Sprint(expected) != fmt.Sprint(got) {
+		t.Error(fmt.Sprintf("Filter %s failed. Expected %v, got %v", name, expected, got))
+	}
+}
+
+func TestFilterComparison(t *testing.T) {
+	preparePeople()
+
+	expectIDs(t, "Eq", []int{4}, filteredIDs(t, jc.Eq("Age", 0)))
+	expectIDs(t, "Ne", []int{1, 2, 3}, filteredIDs(t, jc.Ne("Age", 0)))
+	expectIDs(t, "Gt/Lte", []int{2, 3}, filteredIDs(t, jc.Gt("Age", 20), jc.Lte("age", 40)))
+	expectIDs(t, "In", []int{1, 3}, filteredIDs(t, jc.In("FirstName", "John", "Jack")))
+	expectIDs(t, "Regex", []int{1, 2}, filteredIDs(t, jc.Regex("LastName", "^f", "i")))
+}
+
+func TestFilterLogical(t *testing.T) {
+	preparePeople()
+
+	expectIDs(t, "Or", []int{1, 3}, filteredIDs(t, jc.Or(jc.Eq("Age", 20), jc.Eq("LastName", "Bar"))))
+	expectIDs(t, "Not", []int{3, 4}, filteredIDs(t, jc.Not(jc.Eq("LastName", "Foo"))))
+	expectIDs(t, "And", []int{2}, filteredIDs(t, jc.And(jc.Eq("LastName", "Foo"), jc.Gt("Age", 20))))
+}
+
+func TestFilterCombinedWithRawFilter(t *testing.T) {
+	preparePeople()
+
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+	q.Filter(Person{LastName: "Foo", FirstName: "Jane", Age: 30, MyID: 2}).Where(jc.Gte("Age", 30)).Execute(true)
+	if len(docs) != 1 || docs[0].MyID != 2 {
+		t.Error("Failed to combine struct filter with conditions")
+	}
+
+	q.Filter(jc.Or(jc.Raw(map[string]interface{}{"first_name": "John"}), jc.Eq("MyID", 3))).Execute(true)
+	if len(docs) != 2 {
+		t.Error(fmt.Sprintf("Failed to filter by condition passed to Filter. Expected %d documents, got %d", 2, len(docs)))
+	}
+}
+
+func TestFilterEmptyLogical(t *testing.T) {
+	var docs []Person
+	for name, condition := range map[string]jc.Condition{"And": jc.And(), "Or": jc.Or(), "Nor": jc.Nor()} {
+		q, _ := jc.NewQuery(&docs)
+		if q.Filter(condition).Err() == nil {
+			t.Error(fmt.Sprintf("Filter did not report %s without conditions", name))
+		}
+	}
+}
+
+func TestFilterUnknownField(t *testing.T) {
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+
+	if q.Filter(jc.Eq("NotAField", 1)).Err() == nil {
+		t.Error("Filter did not report unknown field")
+	}
+}
@@ -2,6 +2,9 @@ package tests
 
 import (
 	"github.com/kalcok/jc"
+	"errors"
+	"strings"
+	"time"
 )
 
 // Test Fixtures
@@ -26,6 +29,119 @@ type (
 	}
 )
 
+// Document with validation rules
+type Validated struct {
+	jc.Collection `bson:"-"json:"-"`
+	Name          string   `bson:"name"jc:"required,min=2,max=16"`
+	Code          string   `bson:"code"jc:"regex=^[a-z]{2,4}$"`
+	Age           int      `bson:"age"jc:"min=18"`
+	Tags          []string `bson:"tags"jc:"max=2"`
+}
+
+// Document with automatic timestamps
+type Stamped struct {
+	jc.Collection `bson:"-"json:"-"`
+	MyID          int       `bson:"_id"`
+	Data          string    `bson:"data"`
+	Created       time.Time `bson:"created"jc:"created_at"`
+	Updated       time.Time `bson:"updated"jc:"updated_at"`
+}
+
+// Document with optimistic concurrency control
+type Versioned struct {
+	jc.Collection `bson:"-"json:"-"`
+	MyID          int    `bson:"_id"`
+	Data          string `bson:"data"`
+	Version       int    `bson:"version"jc:"version"`
+}
+
+// Document that is only marked as deleted
+type SoftDeleted struct {
+	jc.Collection `bson:"-"json:"-"jc:",softdelete"`
+	MyID          int       `bson:"_id"`
+	Data          string    `bson:"data"`
+	Deleted       time.Time `bson:"deleted_at,omitempty"`
+}
+
+// Document with declared indexes
+type Indexed struct {
+	jc.Collection `bson:"-"json:"-"jc:",index=LastName|-FirstName"`
+	FirstName     string    `bson:"first_name"`
+	LastName      string    `bson:"last_name"`
+	Email         string    `bson:"email"jc:"unique"`
+	Age           int       `bson:"age"jc:"index=-1"`
+	Expires       time.Time `bson:"expires"jc:"ttl=3600"`
+}
+
+// Document stored through named connection in its own database
+type Routed struct {
+	jc.Collection `bson:"-"json:"-"jc:"routed,conn=routing,db=jc_test_routed"`
+	MyID          int    `bson:"_id"`
+	Data          string `bson:"data"`
+}
+
+// Document read from the nearest member of replica set by default
+type NearestRead struct {
+	jc.Collection `bson:"-"json:"-"jc:"nearest_read,read=nearest"`
+	MyID          int    `bson:"_id"`
+	Data          string `bson:"data"`
+}
+
+// Document with misspelled read preference
+type MisreadPreference struct {
+	jc.Collection `bson:"-"json:"-"jc:",read=secondry"`
+	Data          string `bson:"data"`
+}
+
+// Document referring to connection that is never opened
+type Unrouted struct {
+	jc.Collection `bson:"-"json:"-"jc:",conn=missing"`
+	Data          string `bson:"data"`
+}
+
+// Document with multiple fields
+type Person struct {
+	jc.Collection `bson:"-"json:"-"`
+	MyID          int    `bson:"_id"`
+	FirstName     string `bson:"first_name"`
+	LastName      string `bson:"last_name"`
+	Age           int    `bson:"age"`
+}
+
+// Document with lifecycle hooks
+type Hooked struct {
+	jc.Collection `bson:"-"json:"-"`
+	MyID          int    `bson:"_id"`
+	Data          string `bson:"data"`
+	Saved         bool   `bson:"-"`
+	Loaded        bool   `bson:"-"`
+}
+
+func (h *Hooked) BeforeSave() error {
+	h.Data = strings.TrimSpace(h.Data)
+	if h.Data == "" {
+		return errors.New("data can't be empty")
+	}
+	return nil
+}
+
+func (h *Hooked) AfterSave() error {
+	h.Saved = true
+	return nil
+}
+
+func (h *Hooked) AfterLoad() error {
+	h.Loaded = true
+	return nil
+}
+
+func (h *Hooked) BeforeDelete() error {
+	if h.Data == "protected" {
+		return errors.New("document is protected")
+	}
+	return nil
+}
+
 // Benchmark Fixtures
 type (
 	simpleUserMGO struct {
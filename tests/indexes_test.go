@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+	"reflect"
+	"github.com/kalcok/jc/tools"
+)
+
+func TestEnsureIndexes(t *testing.T) {
+	dropTestDB()
+	expected := [][]string{{"email"}, {"-age"}, {"expires"}, {"last_name", "-first_name"}}
+
+	report, err := jc.EnsureIndexes(&Indexed{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != len(expected) {
+		t.Fatal(fmt.Sprintf("Unexpected number of indexes. Expected %d, got %d", len(expected), len(report)))
+	}
+	for i, index := range report {
+		if !reflect.DeepEqual(index.Key, expected[i]) {
+			t.Error(fmt.Sprintf("Unexpected index key. Expected %v, got %v", expected[i], index.Key))
+		}
+		if !index.Created {
+			t.Error(fmt.Sprintf("Index %v was not reported as created", index.Key))
+		}
+	}
+
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	indexes, _ := session.DB(sessionDB).C("indexed").Indexes()
+	for _, index := range indexes {
+		if reflect.DeepEqual(index.Key, []string{"email"}) && !index.Unique {
+			t.Error("Unique index was created as non-unique")
+		}
+	}
+}
+
+func TestEnsureIndexesExisting(t *testing.T) {
+	dropTestDB()
+	jc.EnsureIndexes(&Indexed{})
+
+	report, err := jc.EnsureIndexes(&Indexed{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range report {
+		if index.Created {
+			t.Error(fmt.Sprintf("Existing index %v was reported as created", index.Key))
+		}
+	}
+}
+
+func TestEnsureIndexesUnknownField(t *testing.T) {
+	type badIndex struct {
+		jc.Collection `bson:"-"json:"-"jc:",index=Missing|Data"`
+		Data          string `bson:"data"`
+	}
+
+	_, err := jc.EnsureIndexes(&badIndex{})
+	if err == nil {
+		t.Error("EnsureIndexes did not fail on index with unknown field")
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestFindAndModifyReturnNew(t *testing.T) {
+	preparePeople()
+
+	var doc Person
+	q, _ := jc.NewQuery(&doc)
+	_, err := q.Filter(jc.Eq("LastName", "Foo")).Sort("-Age").FindAndModify(true, jc.Change{
+		Update:    bson.M{"$inc": bson.M{"age": 1}},
+		ReturnNew: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.MyID != 2 || doc.Age != 31 {
+		t.Error(fmt.Sprintf("FindAndModify did not load modified document. Got %+v", doc))
+	}
+	if !doc.IsInitialized() || doc.IsDirty() {
+		t.Error("Document loaded by FindAndModify was not initialized")
+	}
+}
+
+func TestFindAndModifyReturnOld(t *testing.T) {
+	preparePeople()
+
+	var doc Person
+	q, _ := jc.NewQuery(&doc)
+	q.Filter(jc.Eq("MyID", 1)).FindAndModify(true, jc.Change{Update: bson.M{"$set": bson.M{"age": 99}}})
+	if doc.Age != 20 {
+		t.Error(fmt.Sprintf("FindAndModify did not load original document. Expected age %d, got %d", 20, doc.Age))
+	}
+}
+
+func TestFindAndModifyUpsert(t *testing.T) {
+	dropTestDB()
+
+	var doc Person
+	q, _ := jc.NewQuery(&doc)
+	_, err := q.Filter(jc.Eq("MyID", 50)).FindAndModify(true, jc.Change{
+		Update:    bson.M{"$set": bson.M{"first_name": "Upserted"}},
+		Upsert:    true,
+		ReturnNew: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.MyID != 50 || doc.FirstName != "Upserted" {
+		t.Error(fmt.Sprintf("FindAndModify did not upsert document. Got %+v", doc))
+	}
+}
+
+func TestFindAndModifyNotFound(t *testing.T) {
+	dropTestDB()
+
+	var doc Person
+	q, _ := jc.NewQuery(&doc)
+	_, err := q.FindAndModify(true, jc.Change{Remove: true})
+	if err != jc.ErrNotFound {
+		t.Error(fmt.Sprintf("Expected ErrNotFound, got '%v'", err))
+	}
+
+	var docs []Person
+	q, _ = jc.NewQuery(&docs)
+	_, err = q.FindAndModify(true, jc.Change{Remove: true})
+	if err == nil {
+		t.Error("FindAndModify accepted query with slice target")
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+	"encoding/base64"
+	"gopkg.in/mgo.v2/bson"
+	"github.com/kalcok/jc/tools"
+)
+
+func TestQueryPagination(t *testing.T) {
+	dropTestDB()
+	for i := 1; i <= 7; i++ {
+		doc := ExplicitID{MyID: i, Data: "TestQueryPagination"}
+		jc.NewDocument(&doc)
+		doc.Save(true)
+	}
+
+	var docs []ExplicitID
+	var pages [][]int
+	q, _ := jc.NewQuery(&docs)
+	q.Page(3)
+
+	for cursor, first := "", true; first || cursor != ""; first = false {
+		err := q.After(cursor).Execute(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page []int
+		for _, doc := range docs {
+			page = append(page, doc.MyID)
+		}
+		pages = append(pages, page)
+		cursor = q.NextCursor()
+	}
+
+	if fmt.Sprint(pages) != "[[1 2 3] [4 5 6] [7]]" {
+		t.Error(fmt.Sprintf("Unexpected pages %v", pages))
+	}
+}
+
+func TestQueryPaginationSorted(t *testing.T) {
+	dropTestDB()
+	for _, data := range []string{"a", "b", "b", "c", "a"} {
+		doc := ImplicitID{Data: data}
+		jc.NewDocument(&doc)
+		doc.Save(true)
+	}
+
+	var docs []ImplicitID
+	var seen []string
+	q, _ := jc.NewQuery(&docs)
+	q.Sort("-Data").Page(2).Execute(true)
+	for {
+		for _, doc := range docs {
+			seen = append(seen, doc.Data)
+		}
+		if q.NextCursor() == "" {
+			break
+		}
+		q.After(q.NextCursor()).Execute(true)
+	}
+
+	if fmt.Sprint(seen) != "[c b b a a]" {
+		t.Error(fmt.Sprintf("Pagination with sort did not return all documents in order. Got %v", seen))
+	}
+}
+
+func TestQueryPaginationMissingSortField(t *testing.T) {
+	preparePeople()
+	session, _ := tools.GetSessionClone()
+	defer session.Close()
+	people := session.DB(sessionDB).C("person")
+	people.Insert(bson.M{"_id": 5}, bson.M{"_id": 6, "last_name": nil}, bson.M{"_id": 7})
+
+	for sort, expected := range map[string]string{
+		"LastName":  "[5 6 7 3 4 1 2]",
+		"-LastName": "[1 2 4 3 5 6 7]",
+	} {
+		var docs []Person
+		var seen []int
+		q, _ := jc.NewQuery(&docs)
+		q.Sort(sort).Page(2)
+		for cursor, first := "", true; first || cursor != ""; first = false {
+			err := q.After(cursor).Execute(true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, doc := range docs {
+				seen = append(seen, doc.MyID)
+			}
+			cursor = q.NextCursor()
+		}
+
+		if fmt.Sprint(seen) != expected {
+			t.Error(fmt.Sprintf("Pagination sorted by '%s' with missing values expected %s, got %v", sort, expected, seen))
+		}
+	}
+}
+
+func TestQueryPaginationInvalidCursor(t *testing.T) {
+	var docs []ExplicitID
+	q, _ := jc.NewQuery(&docs)
+
+	if q.Page(2).After("definitely not a cursor").Execute(true) == nil {
+		t.Error("Execute did not fail on invalid cursor")
+	}
+}
+
+func TestQueryPaginationMismatchedCursor(t *testing.T) {
+	var docs []ExplicitID
+	q, _ := jc.NewQuery(&docs)
+
+	// Well formed cursor without values for its keys
+	data, _ := bson.Marshal(bson.M{"k": []string{"_id"}})
+	q.Page(2).After(base64.RawURLEncoding.EncodeToString(data))
+
+	if q.Err() == nil {
+		t.Error("After accepted cursor with missing values")
+	}
+	if q.Execute(true) == nil {
+		t.Error("Execute did not fail on cursor with missing values")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/kalcok/jc"
 	"fmt"
 	"gopkg.in/mgo.v2/bson"
+	"errors"
+	"time"
 )
 
 // Creates <num> of records of ImplicitID type
@@ -242,4 +244,348 @@ func TestQueryFilterStruct(t *testing.T) {
 	if result.Data != expectedData {
 		t.Error("Failed to select records based on struct filter")
 	}
-}
\ No newline at end of file
+}
+func TestQueryResultIsClean(t *testing.T) {
+	dropTestDB()
+	ids := prepareSimpleRecords(1, "TestQueryResultIsClean")
+
+	var doc ImplicitID
+	q, _ := jc.NewQuery(&doc)
+	q.Execute(true)
+
+	if doc.IsDirty() {
+		t.Error("Document loaded by query is reported as dirty")
+	}
+	if doc.ID() != ids[0] {
+		t.Error(fmt.Sprintf("Failed to load implicit ID. Expected '%v', got '%v'", ids[0], doc.ID()))
+	}
+}
+
+func TestQueryResultSaveUpdatesInPlace(t *testing.T) {
+	dropTestDB()
+	prepareSimpleRecords(3, "TestQueryResultSaveUpdatesInPlace")
+
+	var docs []ImplicitID
+	q, _ := jc.NewQuery(&docs)
+	q.Execute(true)
+
+	docs[0].Data = "Updated"
+	docs[0].Save(true)
+
+	q.Execute(true)
+	if len(docs) != 3 {
+		t.Error(fmt.Sprintf("Saving loaded document created new record. Expected %d records, got %d", 3, len(docs)))
+	}
+}
+
+func TestQueryAfterLoadHook(t *testing.T) {
+	dropTestDB()
+	doc := Hooked{MyID: 1, Data: "TestQueryAfterLoadHook"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	var docs []Hooked
+	q, _ := jc.NewQuery(&docs)
+	q.Execute(true)
+
+	for _, record := range docs {
+		if !record.Loaded {
+			t.Error("AfterLoad hook was not called for loaded document")
+		}
+	}
+}
+
+func TestQueryExcludesSoftDeleted(t *testing.T) {
+	dropTestDB()
+	for i := 0; i < 3; i++ {
+		doc := SoftDeleted{MyID: i, Data: "TestQueryExcludesSoftDeleted"}
+		jc.NewDocument(&doc)
+		doc.Save(true)
+	}
+	jc.DeleteByID(&SoftDeleted{}, 0)
+
+	var docs []SoftDeleted
+	q, _ := jc.NewQuery(&docs)
+	q.Filter(bson.M{"data": "TestQueryExcludesSoftDeleted"}).Execute(true)
+	if len(docs) != 2 {
+		t.Error(fmt.Sprintf("Query did not exclude soft deleted documents. Expected %d, got %d", 2, len(docs)))
+	}
+
+	q.WithDeleted().Execute(true)
+	if len(docs) != 3 {
+		t.Error(fmt.Sprintf("Query did not include soft deleted documents. Expected %d, got %d", 3, len(docs)))
+	}
+}
+
+func TestQuerySort(t *testing.T) {
+	dropTestDB()
+	for _, i := range []int{3, 1, 2} {
+		doc := ExplicitID{MyID: i, Data: fmt.Sprintf("TestQuerySort %d", i)}
+		jc.NewDocument(&doc)
+		doc.Save(true)
+	}
+
+	var docs []ExplicitID
+	q, _ := jc.NewQuery(&docs)
+	q.Sort("-MyID").Execute(true)
+	for i, expected := range []int{3, 2, 1} {
+		if docs[i].MyID != expected {
+			t.Error(fmt.Sprintf("Failed to sort by struct field name. Expected ID %d, got %d", expected, docs[i].MyID))
+		}
+	}
+
+	q.Sort("data").Execute(true)
+	for i, expected := range []int{1, 2, 3} {
+		if docs[i].MyID != expected {
+			t.Error(fmt.Sprintf("Failed to sort by bson name. Expected ID %d, got %d", expected, docs[i].MyID))
+		}
+	}
+}
+
+func TestQuerySortUnknownField(t *testing.T) {
+	var docs []ExplicitID
+	q, _ := jc.NewQuery(&docs)
+	q.Sort("NotAField")
+
+	if q.Err() == nil {
+		t.Error("Sort did not report unknown field")
+	}
+	if q.Execute(true) == nil {
+		t.Error("Execute did not fail on query with unknown sort field")
+	}
+}
+
+func TestQuerySelect(t *testing.T) {
+	dropTestDB()
+	doc := Person{MyID: 1, FirstName: "John", LastName: "Foo", Age: 42}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	var result Person
+	q, _ := jc.NewQuery(&result)
+	q.Select("FirstName").Execute(true)
+
+	if result.FirstName != "John" || result.LastName != "" || result.Age != 0 {
+		t.Error(fmt.Sprintf("Failed to load only selected fields. Got %+v", result))
+	}
+	if !result.IsPartial() {
+		t.Error("Document loaded with projection is not marked as partial")
+	}
+}
+
+func TestQueryPartialSave(t *testing.T) {
+	dropTestDB()
+	doc := Person{MyID: 1, FirstName: "John", LastName: "Foo", Age: 42}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	var result Person
+	q, _ := jc.NewQuery(&result)
+	q.Omit("LastName", "age").Execute(true)
+
+	result.FirstName = "Jane"
+	result.Save(true)
+
+	var reloaded Person
+	q, _ = jc.NewQuery(&reloaded)
+	q.Execute(true)
+	if reloaded.FirstName != "Jane" || reloaded.LastName != "Foo" || reloaded.Age != 42 {
+		t.Error(fmt.Sprintf("Saving partial document wiped fields that were not loaded. Got %+v", reloaded))
+	}
+}
+
+func TestQuerySelectMaintainedFields(t *testing.T) {
+	dropTestDB()
+	versioned := Versioned{MyID: 1, Data: "TestQuerySelectMaintainedFields"}
+	jc.NewDocument(&versioned)
+	versioned.Save(true)
+	stamped := Stamped{MyID: 1, Data: "TestQuerySelectMaintainedFields"}
+	jc.NewDocument(&stamped)
+	stamped.Save(true)
+
+	var partialVersioned Versioned
+	q, _ := jc.NewQuery(&partialVersioned)
+	q.Select("Data").Execute(true)
+	partialVersioned.Data = "updated"
+	_, err := partialVersioned.Save(true)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Failed to save versioned document loaded with projection. %s", err))
+	}
+	versioned.Reload(true)
+	if versioned.Version != 2 || versioned.Data != "updated" {
+		t.Error(fmt.Sprintf("Version was not updated by saving partial document. Got %+v", versioned))
+	}
+
+	var partialStamped Stamped
+	q, _ = jc.NewQuery(&partialStamped)
+	q.Select("Data").Execute(true)
+	partialStamped.Data = "updated"
+	time.Sleep(5 * time.Millisecond)
+	_, err = partialStamped.Save(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := stamped.Updated
+	stamped.Reload(true)
+	if !stamped.Updated.After(previous) {
+		t.Error("Update timestamp was not saved with partial document")
+	}
+
+	if q.Omit("Updated").Err() == nil {
+		t.Error("Omit of field maintained by jc was accepted")
+	}
+}
+
+func TestQueryProjectionUnknownField(t *testing.T) {
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+
+	if q.Select("NotAField").Err() == nil {
+		t.Error("Select did not report unknown field")
+	}
+}
+
+func TestQueryCount(t *testing.T) {
+	dropTestDB()
+	prepareSimpleRecords(10, "TestQueryCount")
+
+	var docs []ImplicitID
+	q, _ := jc.NewQuery(&docs)
+
+	count, err := q.Count(true)
+	if err != nil || count != 10 {
+		t.Error(fmt.Sprintf("Failed to count documents. Expected %d, got %d (%v)", 10, count, err))
+	}
+
+	count, _ = q.Skip(2).Limit(5).Count(true)
+	if count != 5 {
+		t.Error(fmt.Sprintf("Count did not respect skip and limit. Expected %d, got %d", 5, count))
+	}
+
+	if docs != nil {
+		t.Error("Count filled query result")
+	}
+}
+
+func TestQueryExists(t *testing.T) {
+	dropTestDB()
+	prepareSimpleRecords(1, "TestQueryExists")
+
+	var doc ImplicitID
+	q, _ := jc.NewQuery(&doc)
+
+	exists, err := q.Filter(bson.M{"data": "TestQueryExists"}).Exists(true)
+	if err != nil || !exists {
+		t.Error("Exists did not find matching document")
+	}
+
+	exists, _ = q.Filter(bson.M{"data": "Missing"}).Exists(true)
+	if exists {
+		t.Error("Exists found document that does not exist")
+	}
+}
+
+func TestQueryDistinct(t *testing.T) {
+	preparePeople()
+
+	var lastNames []string
+	var docs []Person
+	q, _ := jc.NewQuery(&docs)
+	err := q.Filter(jc.Gt("Age", 0)).Distinct(true, "LastName", &lastNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lastNames) != 2 {
+		t.Error(fmt.Sprintf("Unexpected distinct values. Expected [Foo Bar], got %v", lastNames))
+	}
+
+	if q.Distinct(true, "NotAField", &lastNames) == nil {
+		t.Error("Distinct did not report unknown field")
+	}
+}
+
+func TestQueryIter(t *testing.T) {
+	dropTestDB()
+	ids := prepareSimpleRecords(5, "TestQueryIter")
+
+	var docs []ImplicitID
+	q, _ := jc.NewQuery(&docs)
+	iter, err := q.Batch(2).Iter(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := 0
+	var doc ImplicitID
+	for iter.Next(&doc) {
+		if doc.Data != "TestQueryIter" || !doc.IsInitialized() {
+			t.Error("Iterator yielded document that was not properly loaded")
+		}
+		seen++
+	}
+	if err = iter.Close(); err != nil {
+		t.Error(err)
+	}
+	if seen != len(ids) {
+		t.Error(fmt.Sprintf("Iterator did not yield all documents. Expected %d, got %d", len(ids), seen))
+	}
+	if docs != nil {
+		t.Error("Iterator filled query result")
+	}
+}
+
+func TestQueryIterWrongType(t *testing.T) {
+	dropTestDB()
+	prepareSimpleRecords(1, "TestQueryIterWrongType")
+
+	var docs []ImplicitID
+	q, _ := jc.NewQuery(&docs)
+	iter, _ := q.Iter(true)
+
+	var doc ExplicitID
+	if iter.Next(&doc) {
+		t.Error("Iterator accepted document of different model")
+	}
+	if iter.Close() == nil {
+		t.Error("Iterator did not report document of different model")
+	}
+}
+
+func TestQueryIterNilDocument(t *testing.T) {
+	dropTestDB()
+	prepareSimpleRecords(1, "TestQueryIterNilDocument")
+
+	var docs []ImplicitID
+	q, _ := jc.NewQuery(&docs)
+	iter, _ := q.Iter(true)
+
+	if iter.Next(nil) {
+		t.Error("Iterator accepted nil document")
+	}
+	if iter.Close() == nil {
+		t.Error("Iterator did not report nil document")
+	}
+}
+
+func TestQueryForEach(t *testing.T) {
+	dropTestDB()
+	prepareSimpleRecords(5, "TestQueryForEach")
+
+	var docs []ImplicitID
+	q, _ := jc.NewQuery(&docs)
+
+	seen := 0
+	err := q.ForEach(true, func(doc interface{}) error {
+		seen++
+		if seen == 3 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Error(fmt.Sprintf("ForEach did not return callback error. Got '%v'", err))
+	}
+	if seen != 3 {
+		t.Error(fmt.Sprintf("ForEach did not stop on callback error. Expected %d calls, got %d", 3, seen))
+	}
+}
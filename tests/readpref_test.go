@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"gopkg.in/mgo.v2"
+	"fmt"
+)
+
+func TestQueryReadPreference(t *testing.T) {
+	dropTestDB()
+	doc := ExplicitID{MyID: 1, Data: "TestQueryReadPreference"}
+	jc.NewDocument(&doc)
+	doc.Save(true)
+
+	var loaded []ExplicitID
+	q, _ := jc.NewQuery(&loaded)
+	err := q.ReadPreference("secondaryPreferred").Execute(true)
+	if err != nil || len(loaded) != 1 {
+		t.Error(fmt.Sprintf("Query with read preference failed. Got %v (%v)", loaded, err))
+	}
+
+	count, err := q.Consistency(mgo.Monotonic).Count(false)
+	if err != nil || count != 1 {
+		t.Error(fmt.Sprintf("Query with consistency mode returned %d (%v)", count, err))
+	}
+}
+
+func TestQueryUnknownReadPreference(t *testing.T) {
+	var loaded []ExplicitID
+	q, _ := jc.NewQuery(&loaded)
+	q.ReadPreference("somewhere")
+
+	if q.Err() == nil {
+		t.Error("Unknown read preference was accepted")
+	}
+	if q.Execute(true) == nil {
+		t.Error("Query with unknown read preference executed without error")
+	}
+}
+
+func TestModelReadPreference(t *testing.T) {
+	dropTestDB()
+	doc := NearestRead{MyID: 1, Data: "TestModelReadPreference"}
+	jc.NewDocument(&doc)
+	_, err := doc.Save(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded NearestRead
+	q, _ := jc.NewQuery(&loaded)
+	err = q.Execute(true)
+	if err != nil || loaded.Data != doc.Data {
+		t.Error(fmt.Sprintf("Query of model with read preference failed. Got %v (%v)", loaded, err))
+	}
+
+	misread := MisreadPreference{Data: "TestModelReadPreference"}
+	jc.NewDocument(&misread)
+	_, err = misread.Save(true)
+	if err == nil {
+		t.Error("Model with unknown read preference saved without error")
+	}
+}
+
+func TestQueryMisreadPreference(t *testing.T) {
+	var loaded []MisreadPreference
+	q, _ := jc.NewQuery(&loaded)
+
+	if q.Err() == nil {
+		t.Error("Query of model with unknown read preference reported no error")
+	}
+	if q.Execute(true) == nil {
+		t.Error("Query of model with unknown read preference executed without error")
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc/tools"
+	"github.com/kalcok/jc"
+	"time"
+	"fmt"
+)
+
+func TestOpenSessionFailure(t *testing.T) {
+	conf := tools.SessionConf{Addrs: []string{"127.0.0.1:1"}, Timeout: 100 * time.Millisecond}
+	retry := tools.RetryPolicy{Attempts: 2, Backoff: 10 * time.Millisecond}
+
+	err := tools.OpenSession(&conf, retry)
+	if err == nil {
+		t.Error("OpenSession did not fail on unreachable server")
+	}
+
+	// Master session must survive failed connection attempt
+	err = tools.Ping()
+	if err != nil {
+		t.Error(fmt.Sprintf("Master session unusable after failed OpenSession. %s", err))
+	}
+}
+
+func TestPing(t *testing.T) {
+	err := tools.Ping()
+	if err != nil {
+		t.Error(fmt.Sprintf("Ping of initialized session failed. %s", err))
+	}
+}
+
+func TestNamedSession(t *testing.T) {
+	err := tools.OpenNamedSession("routing", &sessionConf, tools.RetryPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tools.CloseNamedSession("routing")
+
+	doc := Routed{MyID: 1, Data: "TestNamedSession"}
+	jc.NewDocument(&doc)
+	if doc.Connection() != "routing" || doc.Database() != "jc_test_routed" {
+		t.Error(fmt.Sprintf("Document routed to '%s' connection and '%s' database", doc.Connection(), doc.Database()))
+	}
+
+	_, err = doc.Save(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, _ := tools.GetNamedSessionClone("routing")
+	defer session.Close()
+	defer session.DB("jc_test_routed").DropDatabase()
+	count, _ := session.DB("jc_test_routed").C("routed").FindId(1).Count()
+	if count != 1 {
+		t.Error("Document was not saved into database of the model")
+	}
+
+	var loaded []Routed
+	q, _ := jc.NewQuery(&loaded)
+	err = q.Execute(true)
+	if err != nil || len(loaded) != 1 || loaded[0].Data != doc.Data {
+		t.Error(fmt.Sprintf("Query did not load routed document. Got %v (%v)", loaded, err))
+	}
+}
+
+func TestMissingNamedSession(t *testing.T) {
+	doc := Unrouted{}
+	if jc.NewDocument(&doc) == nil {
+		t.Error("Document with unknown connection initialized without error")
+	}
+
+	_, err := doc.Save(true)
+	if err == nil {
+		t.Error("Document with unknown connection saved without error")
+	}
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+	"github.com/kalcok/jc"
+	"fmt"
+)
+
+func TestValidationPasses(t *testing.T) {
+	doc := Validated{Name: "John", Code: "abc", Age: 30, Tags: []string{"a"}}
+	jc.NewDocument(&doc)
+
+	err := doc.Validate()
+	if err != nil {
+		t.Error(fmt.Sprintf("Valid document failed validation: %s", err))
+	}
+}
+
+func TestValidationListsAllFields(t *testing.T) {
+	doc := Validated{Code: "toolong", Age: 10, Tags: []string{"a", "b", "c"}}
+	jc.NewDocument(&doc)
+
+	err := doc.Validate()
+	validationErr, ok := err.(*jc.ValidationError)
+	if !ok {
+		t.Fatal(fmt.Sprintf("Expected *ValidationError, got '%v'", err))
+	}
+
+	failed := map[string]bool{}
+	for _, field := range validationErr.Fields {
+		failed[field.Field+":"+field.Rule] = true
+	}
+	for _, expected := range []string{"name:required", "name:min", "code:regex", "age:min", "tags:max"} {
+		if !failed[expected] {
+			t.Error(fmt.Sprintf("Validation did not report '%s'. Got %v", expected, validationErr.Fields))
+		}
+	}
+}
+
+func TestSaveValidates(t *testing.T) {
+	doc := Validated{Name: "J", Code: "abc", Age: 30}
+	jc.NewDocument(&doc)
+
+	_, err := doc.Save(true)
+	if _, ok := err.(*jc.ValidationError); !ok {
+		t.Error(fmt.Sprintf("Save did not fail on invalid document. Got '%v'", err))
+	}
+
+	err = doc.Insert(true)
+	if _, ok := err.(*jc.ValidationError); !ok {
+		t.Error(fmt.Sprintf("Insert did not fail on invalid document. Got '%v'", err))
+	}
+}
+
+func TestInvalidRuleDefinition(t *testing.T) {
+	type badRule struct {
+		jc.Collection `bson:"-"json:"-"`
+		Data          string `bson:"data"jc:"min=one"`
+	}
+
+	doc := badRule{Data: "TestInvalidRuleDefinition"}
+	jc.NewDocument(&doc)
+
+	if doc.Validate() == nil {
+		t.Error("Invalid rule definition was not reported")
+	}
+}
+
+func TestUnknownTagOption(t *testing.T) {
+	type misspelledRule struct {
+		jc.Collection `bson:"-"json:"-"`
+		Data          string `bson:"data"jc:"requird"`
+	}
+	type misspelledOption struct {
+		jc.Collection `bson:"-"json:"-"jc:",sofdelete"`
+		Data          string `bson:"data"`
+	}
+
+	rule := misspelledRule{Data: "TestUnknownTagOption"}
+	jc.NewDocument(&rule)
+	if rule.Validate() == nil {
+		t.Error("Unknown option of field tag was not reported")
+	}
+
+	option := misspelledOption{Data: "TestUnknownTagOption"}
+	jc.NewDocument(&option)
+	if option.Validate() == nil {
+		t.Error("Unknown option of Collection tag was not reported")
+	}
+}
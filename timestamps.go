@@ -0,0 +1,45 @@
+package jc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// fieldRef identifies model field with special meaning for jc
+type fieldRef struct {
+	name string
+	key  string
+}
+
+func (f fieldRef) defined() bool {
+	return f.name != ""
+}
+
+// timestamp returns current time rounded to precision of dates stored in DB
+func timestamp() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
+
+// parseTimestampTag recognizes 'created_at' and 'updated_at' options of the field
+func (c *Collection) parseTimestampTag(field reflect.StructField, key string, options map[string]string) error {
+	for option, ref := range map[string]*fieldRef{"created_at": &c._createdAt, "updated_at": &c._updatedAt} {
+		if _, found := options[option]; !found {
+			continue
+		}
+		if field.Type != reflect.TypeOf(time.Time{}) {
+			return errors.New(fmt.Sprintf("'%s' field '%s' must be of type time.Time", option, field.Name))
+		}
+		*ref = fieldRef{name: field.Name, key: key}
+	}
+	return nil
+}
+
+func (c *Collection) timeField(ref fieldRef) time.Time {
+	return c._parent.Elem().FieldByName(ref.name).Interface().(time.Time)
+}
+
+func (c *Collection) setTimeField(ref fieldRef, value time.Time) {
+	c._parent.Elem().FieldByName(ref.name).Set(reflect.ValueOf(value))
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"gopkg.in/mgo.v2"
+)
+
+// TLSConf describes TLS connection to the DB server. CertFile is PEM file
+// containing both client certificate and its private key.
+type TLSConf struct {
+	CAFile   string
+	CertFile string
+	Insecure bool
+}
+
+// ConfFromURL builds session configuration from mongodb:// connection string.
+// Besides options supported by mgo.ParseURL, options 'ssl' (or 'tls'),
+// 'tlsCAFile', 'tlsCertificateKeyFile', 'tlsInsecure' and 'connectTimeoutMS'
+// are recognized.
+func ConfFromURL(uri string) (*SessionConf, error) {
+	var tlsConf *TLSConf
+	var timeout time.Duration
+	var mgoOptions []string
+
+	base, query := uri, ""
+	if i := strings.Index(uri, "?"); i >= 0 {
+		base, query = uri[:i], uri[i+1:]
+	}
+
+	for _, pair := range strings.FieldsFunc(query, func(r rune) bool { return r == '&' || r == ';' }) {
+		option := strings.SplitN(pair, "=", 2)
+		if len(option) != 2 {
+			// Let mgo report malformed option
+			mgoOptions = append(mgoOptions, pair)
+			continue
+		}
+		name, value := option[0], option[1]
+
+		switch name {
+		case "ssl", "tls":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("bad value for %s: %s", name, value))
+			}
+			if enabled && tlsConf == nil {
+				tlsConf = &TLSConf{}
+			}
+		case "tlsCAFile", "tlsCertificateKeyFile", "tlsInsecure":
+			if tlsConf == nil {
+				tlsConf = &TLSConf{}
+			}
+			switch name {
+			case "tlsCAFile":
+				tlsConf.CAFile = value
+			case "tlsCertificateKeyFile":
+				tlsConf.CertFile = value
+			default:
+				insecure, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, errors.New(fmt.Sprintf("bad value for %s: %s", name, value))
+				}
+				tlsConf.Insecure = insecure
+			}
+		case "connectTimeoutMS":
+			ms, err := strconv.Atoi(value)
+			if err != nil || ms < 0 {
+				return nil, errors.New(fmt.Sprintf("bad value for %s: %s", name, value))
+			}
+			timeout = time.Duration(ms) * time.Millisecond
+		default:
+			mgoOptions = append(mgoOptions, pair)
+		}
+	}
+
+	if len(mgoOptions) > 0 {
+		base += "?" + strings.Join(mgoOptions, "&")
+	}
+	info, err := mgo.ParseURL(base)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := (*SessionConf)(info)
+	if timeout > 0 {
+		conf.Timeout = timeout
+	}
+	if tlsConf != nil {
+		err = conf.EnableTLS(tlsConf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// ConfFromEnv builds session configuration from environment variables
+// with 'JC_' prefix. See ConfFromEnvPrefix for the list of variables.
+func ConfFromEnv() (*SessionConf, error) {
+	return ConfFromEnvPrefix("JC_")
+}
+
+// ConfFromEnvPrefix builds session configuration from environment variables
+// starting with given prefix:
+//
+//	URI            mongodb:// connection string, other variables override its values
+//	ADDRESS        comma separated list of servers, defaults to 'localhost'
+//	DB             database
+//	USER           user name
+//	PASS           password
+//	AUTH_SOURCE    database holding user credentials
+//	TIMEOUT        dial timeout, e.g. '5s'
+//	TLS            'true' enables TLS
+//	TLS_CA_FILE    PEM file with certificate authorities (enables TLS)
+//	TLS_CERT_FILE  PEM file with client certificate and key (enables TLS)
+//	TLS_INSECURE   'true' skips verification of server certificate
+func ConfFromEnvPrefix(prefix string) (*SessionConf, error) {
+	var err error
+	conf := &SessionConf{}
+	env := func(name string) (string, bool) {
+		return os.LookupEnv(prefix + name)
+	}
+
+	if uri, found := env("URI"); found {
+		conf, err = ConfFromURL(uri)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if addresses, found := env("ADDRESS"); found {
+		conf.Addrs = strings.Split(addresses, ",")
+	} else if len(conf.Addrs) == 0 {
+		conf.Addrs = []string{"localhost"}
+	}
+	if db, found := env("DB"); found {
+		conf.Database = db
+	}
+	if user, found := env("USER"); found {
+		conf.Username = user
+	}
+	if pass, found := env("PASS"); found {
+		conf.Password = pass
+	}
+	if source, found := env("AUTH_SOURCE"); found {
+		conf.Source = source
+	}
+	if timeout, found := env("TIMEOUT"); found {
+		conf.Timeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("bad value for %sTIMEOUT: %s", prefix, timeout))
+		}
+	}
+
+	tlsConf := TLSConf{}
+	enabled := false
+	if value, found := env("TLS"); found {
+		enabled, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("bad value for %sTLS: %s", prefix, value))
+		}
+	}
+	if value, found := env("TLS_INSECURE"); found {
+		tlsConf.Insecure, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("bad value for %sTLS_INSECURE: %s", prefix, value))
+		}
+	}
+	tlsConf.CAFile, _ = env("TLS_CA_FILE")
+	tlsConf.CertFile, _ = env("TLS_CERT_FILE")
+	if enabled || tlsConf.CAFile != "" || tlsConf.CertFile != "" {
+		err = conf.EnableTLS(&tlsConf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// EnableTLS makes session connect to servers over TLS
+func (conf *SessionConf) EnableTLS(tlsConf *TLSConf) error {
+	config := &tls.Config{InsecureSkipVerify: tlsConf.Insecure}
+
+	if tlsConf.CAFile != "" {
+		pem, err := ioutil.ReadFile(tlsConf.CAFile)
+		if err != nil {
+			return err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return errors.New(fmt.Sprintf("no certificates found in '%s'", tlsConf.CAFile))
+		}
+	}
+
+	if tlsConf.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(tlsConf.CertFile, tlsConf.CertFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	conf.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+		timeout := conf.Timeout
+		if timeout == 0 {
+			timeout = DefaultDialTimeout
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr.String(), config)
+	}
+	return nil
+}
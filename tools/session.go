@@ -4,41 +4,187 @@ import (
 	"gopkg.in/mgo.v2"
 	"fmt"
 	"errors"
+	"time"
+	"sync"
 )
 
 type SessionConf mgo.DialInfo
 
+// RetryPolicy controls how many times OpenSession tries to connect to the
+// DB server. Wait between attempts starts at Backoff and doubles after each
+// failed attempt, up to MaxBackoff (if set).
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultDialTimeout limits connecting to the server when SessionConf does
+// not specify Timeout
+const DefaultDialTimeout = 10 * time.Second
+
 var (
-	session *mgo.Session
+	session     *mgo.Session
+	named       = make(map[string]*mgo.Session)
+	sessionLock sync.RWMutex
 )
 
+// InitSession connects to the DB server and panics if connection fails.
+// Use OpenSession to handle connection failures.
 func InitSession(conf *SessionConf) {
+	err := OpenSession(conf, RetryPolicy{})
+
+	if err != nil {
+		panic(err.Error())
+	}
+}
+
+// OpenSession connects to the DB server, retrying failed attempts according
+// to retry policy. Master session is replaced only if connection succeeds.
+func OpenSession(conf *SessionConf, retry RetryPolicy) error {
+	newSession, err := dial(conf, retry)
+	if err != nil {
+		return err
+	}
+
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	if session != nil {
+		session.Close()
+	}
+	session = newSession
+	return nil
+}
+
+// OpenNamedSession connects to the DB server like OpenSession, but registers
+// resulting session under given name instead of replacing master session.
+// Models select named session with 'conn' option of Collection tag. Empty
+// name refers to master session.
+func OpenNamedSession(name string, conf *SessionConf, retry RetryPolicy) error {
+	if name == "" {
+		return OpenSession(conf, retry)
+	}
+
+	newSession, err := dial(conf, retry)
+	if err != nil {
+		return err
+	}
+
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	if old, found := named[name]; found {
+		old.Close()
+	}
+	named[name] = newSession
+	return nil
+}
+
+func dial(conf *SessionConf, retry RetryPolicy) (*mgo.Session, error) {
+	info := *(*mgo.DialInfo)(conf)
+	if info.Timeout == 0 {
+		info.Timeout = DefaultDialTimeout
+	}
+
+	attempts := retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
 	var err error
-	session, err = mgo.DialWithInfo((*mgo.DialInfo)(conf))
+	var newSession *mgo.Session
+	backoff := retry.Backoff
+	for attempt := 1; attempt <= attempts; attempt++ {
+		newSession, err = mgo.DialWithInfo(&info)
+		if err == nil {
+			if conf.Timeout == 0 {
+				// Default timeout applies only to dialing, session keeps operating without timeouts
+				newSession.SetSyncTimeout(0)
+				newSession.SetSocketTimeout(0)
+			}
+			return newSession, nil
+		}
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+			if retry.MaxBackoff > 0 && backoff > retry.MaxBackoff {
+				backoff = retry.MaxBackoff
+			}
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("Failed to connect to DB server after %d attempt(s). %s", attempts, err))
+}
+
+// Ping checks that master session is initialized and DB server is reachable
+func Ping() error {
+	return PingNamed("")
+}
 
+// PingNamed checks that named session is initialized and DB server is reachable
+func PingNamed(name string) error {
+	s, err := GetNamedSessionCopy(name)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to connect to DB server. %s", err))
+		return err
 	}
+	defer s.Close()
+	return s.Ping()
 }
 
 func CloseSession() {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
 	if session != nil {
 		session.Close()
+		session = nil
 	}
 }
 
-func GetSessionCopy() (*mgo.Session, error) {
-	var err error
-	if session == nil {
-		err = errors.New("Session is not initialized")
+// CloseNamedSession closes and unregisters named session
+func CloseNamedSession(name string) {
+	if name == "" {
+		CloseSession()
+		return
+	}
+
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	if s, found := named[name]; found {
+		s.Close()
+		delete(named, name)
 	}
-	return session.Copy(), err
+}
+
+func GetSessionCopy() (*mgo.Session, error) {
+	return GetNamedSessionCopy("")
 }
 
 func GetSessionClone() (*mgo.Session, error) {
-	var err error
-	if session == nil {
-		err = errors.New("Session is not initialized")
+	return GetNamedSessionClone("")
+}
+
+func GetNamedSessionCopy(name string) (*mgo.Session, error) {
+	return acquire(name, (*mgo.Session).Copy)
+}
+
+func GetNamedSessionClone(name string) (*mgo.Session, error) {
+	return acquire(name, (*mgo.Session).Clone)
+}
+
+// acquire derives new session from the named one. Sessions are derived while
+// holding the lock, so that they can't be closed in the meantime.
+func acquire(name string, derive func(*mgo.Session) *mgo.Session) (*mgo.Session, error) {
+	sessionLock.RLock()
+	defer sessionLock.RUnlock()
+
+	if name == "" {
+		if session == nil {
+			return nil, errors.New("Session is not initialized")
+		}
+		return derive(session), nil
 	}
-	return session.Clone(), err
-}
\ No newline at end of file
+
+	s, found := named[name]
+	if !found {
+		return nil, errors.New(fmt.Sprintf("Session '%s' is not initialized", name))
+	}
+	return derive(s), nil
+}
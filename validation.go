@@ -0,0 +1,158 @@
+package jc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes single validation rule violated by document field
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// ValidationError lists every field of the document that failed validation.
+// Fields are identified by their bson names.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var messages []string
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+	return "validation failed (" + strings.Join(messages, "; ") + ")"
+}
+
+// fieldRule holds validation rules parsed from 'jc' tag of the model field
+type fieldRule struct {
+	field    string
+	key      string
+	required bool
+	hasMin   bool
+	min      float64
+	hasMax   bool
+	max      float64
+	pattern  *regexp.Regexp
+}
+
+func newFieldRule(field reflect.StructField, key string, options map[string]string) (*fieldRule, error) {
+	var err error
+	rule := fieldRule{field: field.Name, key: key}
+	active := false
+
+	if _, found := options["required"]; found {
+		rule.required = true
+		active = true
+	}
+	if value, found := options["min"]; found {
+		rule.hasMin = true
+		active = true
+		rule.min, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid 'min' rule on field '%s': %s", field.Name, err))
+		}
+	}
+	if value, found := options["max"]; found {
+		rule.hasMax = true
+		active = true
+		rule.max, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid 'max' rule on field '%s': %s", field.Name, err))
+		}
+	}
+	if value, found := options["regex"]; found {
+		active = true
+		rule.pattern, err = regexp.Compile(value)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid 'regex' rule on field '%s': %s", field.Name, err))
+		}
+	}
+
+	if !active {
+		return nil, nil
+	}
+	return &rule, nil
+}
+
+func (r *fieldRule) check(value reflect.Value) (errs []FieldError) {
+	if r.required && isEmpty(value) {
+		errs = append(errs, FieldError{Field: r.key, Rule: "required", Message: "is required"})
+	}
+
+	size, measurable := measure(value)
+	if r.hasMin && measurable && size < r.min {
+		errs = append(errs, FieldError{
+			Field:   r.key,
+			Rule:    "min",
+			Message: fmt.Sprintf("must be at least %v", r.min)})
+	}
+	if r.hasMax && measurable && size > r.max {
+		errs = append(errs, FieldError{
+			Field:   r.key,
+			Rule:    "max",
+			Message: fmt.Sprintf("must be at most %v", r.max)})
+	}
+
+	if r.pattern != nil && value.Kind() == reflect.String && !r.pattern.MatchString(value.String()) {
+		errs = append(errs, FieldError{
+			Field:   r.key,
+			Rule:    "regex",
+			Message: fmt.Sprintf("must match '%s'", r.pattern.String())})
+	}
+	return errs
+}
+
+// Validate checks document against rules defined in 'jc' tags of its fields.
+// It returns *ValidationError listing all fields that failed validation.
+func (c *Collection) Validate() error {
+	if c._tagError != nil {
+		return c._tagError
+	}
+
+	var result ValidationError
+	for _, rule := range c._rules {
+		value := c._parent.Elem().FieldByName(rule.field)
+		result.Fields = append(result.Fields, rule.check(value)...)
+	}
+
+	if len(result.Fields) > 0 {
+		return &result
+	}
+	return nil
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+// measure returns value that is compared with 'min' and 'max' rules. Numbers
+// are compared by their value, strings and collections by their length.
+func measure(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,28 @@
+package jc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// parseVersionTag recognizes 'version' option of the field
+func (c *Collection) parseVersionTag(field reflect.StructField, key string, options map[string]string) error {
+	if _, found := options["version"]; !found {
+		return nil
+	}
+	switch field.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c._version = fieldRef{name: field.Name, key: key}
+		return nil
+	}
+	return errors.New(fmt.Sprintf("'version' field '%s' must be an integer", field.Name))
+}
+
+func (c *Collection) version() int64 {
+	return c._parent.Elem().FieldByName(c._version.name).Int()
+}
+
+func (c *Collection) setVersion(version int64) {
+	c._parent.Elem().FieldByName(c._version.name).SetInt(version)
+}